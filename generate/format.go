@@ -0,0 +1,78 @@
+package generate
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// formatSource removes the unused imports from the generated source and formats it.
+// Templates import every package they may refer to so imports which are only used by
+// some requests are dropped here.
+func formatSource(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	// Deleting an import modifies f.Imports so the unused imports are collected first
+	var unused []*ast.ImportSpec
+	for _, spec := range f.Imports {
+		if name, known := importName(spec); known && !used[name] {
+			unused = append(unused, spec)
+		}
+	}
+	for _, spec := range unused {
+		var alias string
+		if spec.Name != nil {
+			alias = spec.Name.Name
+		}
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		astutil.DeleteNamedImport(fset, f, alias, importPath)
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// importName returns the name an import is referred to by. The name of an import
+// without an explicit name is only known when the last element of its path is a
+// valid identifier.
+func importName(spec *ast.ImportSpec) (string, bool) {
+	if spec.Name != nil {
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return "", false
+		}
+		return spec.Name.Name, true
+	}
+	importPath, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return "", false
+	}
+	name := path.Base(importPath)
+	if !token.IsIdentifier(name) || strings.HasPrefix(importPath, "gopkg.in/") {
+		return "", false
+	}
+	return name, true
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
-	"go/format"
-	"log"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/jsaund/gorest/parse"
@@ -13,302 +15,452 @@ import (
 
 var funcMap = template.FuncMap{
 	"ParamsList":      getParamsList,
+	"ArgsList":        getArgsList,
 	"ParamName":       getParamName,
+	"ParamArg":        getParamArg,
+	"AnnotationKey":   getAnnotationKey,
 	"AnnotationValue": getAnnotationValue,
+	"AnnotationArg":   getAnnotationArg,
+	"AnnotationFlag":  getAnnotationFlag,
 	"FunctionName":    getFunctionName,
+	"ReceiverName":    getReceiverName,
+	"Param":           newParam,
+	"Quote":           strconv.Quote,
 }
 
-// Generate generates the implementation using the details contained in ParseResult.
-func Generate(r *parse.ParseResult) ([]byte, error) {
-	var builderTemplate = template.Must(template.New("builder").Funcs(funcMap).Parse(`/*
-* CODE GENERATED AUTOMATICALLY WITH GOREST (github.com/jsaund/gorest)
-* THIS FILE SHOULD NOT BE EDITED BY HAND
-*/
-
-package {{.PackageName}}
-
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"mime/multipart"
-	"net/http"
-	"net/url"
-	"strings"
-
-	"github.com/jsaund/gorest/restclient"
-)
-
-{{ if .CallbackType }}
-type {{ $.CallbackType }} interface {
-	OnStart()
-	OnError(reason string)
-	OnSuccess(response {{ $.ResponseType }})
+// mockFields are the fields of a request builder mock, see mockTemplate.
+var mockFields = map[string]bool{
+	"Calls":               true,
+	"PathSubstitutions":   true,
+	"QueryParams":         true,
+	"EncodedQueryParams":  true,
+	"PostFormParams":      true,
+	"PostBody":            true,
+	"PostMultiPartParams": true,
+	"HeaderParams":        true,
+	"DynamicURL":          true,
+	"Response":            true,
+	"Err":                 true,
 }
-{{ end }}
-
-type {{ .RequestType }}Impl struct {
-	pathSubstitutions  map[string]string
-	queryParams        url.Values
-	postFormParams     url.Values
-	postBody           interface{}
-	postMultiPartParam map[string][]byte
-	headerParams       map[string]string
+
+// importSpec is an import of a generated file, Name is empty unless the package is
+// imported with another name.
+type importSpec struct {
+	Name string
+	Path string
 }
 
-func New{{ .RequestType }}() {{ .RequestType }} {
-	return &{{ .RequestType }}Impl{
-		pathSubstitutions:  make(map[string]string),
-		queryParams:        url.Values{},
-		postFormParams:     url.Values{},
-		postMultiPartParam: make(map[string][]byte),
-		headerParams:       make(map[string]string),
+func (i importSpec) String() string {
+	if i.Name != "" {
+		return i.Name + " " + strconv.Quote(i.Path)
 	}
+	return strconv.Quote(i.Path)
 }
 
-{{ range $key, $value := .PathSubstitutions }}
-func (b *{{ $.RequestType }}Impl) {{ $key }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
-	b.pathSubstitutions["{{ AnnotationValue $value }}"] = {{ ParamName $value.Type true 0 }}
-	return b
-}
-{{ end }}
+// builderImports and mockImports are the imports of the generated files. The generated
+// code refers to net/url as neturl so it is not shadowed by a parameter named url. io,
+// os, time and url are imported for the parameter and response types, which have always
+// been able to refer to them. The imports which are not used are removed by formatSource.
+var (
+	builderImports = []importSpec{
+		{Path: "bytes"}, {Path: "context"}, {Path: "fmt"}, {Path: "io"}, {Path: "net/http"},
+		{Path: "net/url"}, {Name: "neturl", Path: "net/url"}, {Path: "os"}, {Path: "strings"},
+		{Path: "time"}, {Path: "github.com/jsaund/gorest/restclient"},
+	}
+	mockImports = []importSpec{
+		{Path: "context"}, {Path: "fmt"}, {Path: "io"}, {Path: "net/http"},
+		{Path: "net/url"}, {Name: "neturl", Path: "net/url"}, {Path: "os"}, {Path: "strings"},
+		{Path: "time"}, {Path: "github.com/jsaund/gorest/restclient"},
+	}
+)
 
-{{ range $key, $value := .QueryParams }}
-func (b *{{ $.RequestType }}Impl) {{ $key }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
-	b.queryParams.Add("{{ AnnotationValue $value }}", {{ ParamName $value.Type true 0 }})
-	return b
+// file is the data passed to fileTemplate. The imports of the standard library are
+// grouped apart from the others.
+type file struct {
+	PackageName string
+	StdImports  []importSpec
+	Imports     []importSpec
+	Callbacks   []*parse.ParseResult
+	Requests    []*parse.ParseResult
+	Services    []*service
 }
-{{ end }}
 
-{{ range $key, $value := .PostFormParams }}
-func (b *{{ $.RequestType }}Impl) {{ $key }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
-	b.postFormParams.Add("{{ AnnotationValue $value }}", {{ ParamName $value.Type true 0 }})
-	return b
+// service is the data passed to serviceTemplate, the requests are those of its methods
+// in the order they are declared.
+type service struct {
+	ServiceType string
+	Requests    []*parse.ParseResult
 }
-{{ end }}
 
-{{ range $key, $value := .PostParams }}
-func (b *{{ $.RequestType }}Impl) {{ $key }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
-	b.postBody = {{ ParamName $value.Type false 0 }}
-	return b
+// param is the data passed to paramTemplate. Builder is the name of the variable holding
+// the request builder the parameter is added to.
+type param struct {
+	Builder string
+	Field   *ast.Field
 }
-{{ end }}
 
-{{ range $key, $value := .HeaderParams }}
-func (b *{{ $.RequestType }}Impl) {{ $key }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
-	b.headerParams["{{ AnnotationValue $value }}"] = {{ ParamName $value.Type true 0 }}
-	return b
+func newParam(builder string, f *ast.Field) param {
+	return param{Builder: builder, Field: f}
 }
-{{ end }}
 
-{{ range $key, $value := .PostMultiPartParams }}
-func (b *{{ $.RequestType }}Impl) {{ $key }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
-	b.postMultiPartParams["{{ AnnotationValue $value }}"] = {{ ParamName $value.Type true 0 }}
-	return b
-}
-{{ end }}
+var fileTmpl = template.Must(template.Must(template.Must(template.Must(template.New("file").Funcs(funcMap).Parse(fileTemplate)).Parse(builderTemplate)).Parse(paramTemplate)).Parse(serviceTemplate))
 
-func (b *{{ .RequestType }}Impl) applyPathSubstituions(api string) string {
-	if len(b.pathSubstitutions) == 0 {
-		return api
-	}
+var mockFileTmpl = template.Must(template.Must(template.Must(template.New("mockFile").Funcs(funcMap).Parse(mockFileTemplate)).Parse(mockTemplate)).Parse(serviceMockTemplate))
 
-	for key, value := range b.pathSubstitutions {
-		api = strings.Replace(api, "{" + key + "}", value, -1)
+// Generate generates the implementation of every request builder contained in results.
+// The request builders are written to a single file in the order they were parsed.
+func Generate(results []*parse.ParseResult) ([]byte, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no request builder interfaces to generate")
 	}
 
-	return api
-}
+	f, err := newFile(results, builderImports)
+	if err != nil {
+		return nil, err
+	}
 
-func (b *{{ .RequestType }}Impl) build() (req *http.Request, err error) {
-	restClient := restclient.GetClient()
-	if restClient == nil {
-		return nil, fmt.Errorf("A rest client has not been registered yet. You must call client.RegisterClient first")
-	}
-	url := restClient.BaseURL() + b.applyPathSubstituions("{{ .ApiEndpoint }}")
-	httpMethod := "{{ .HttpMethod }}"
-	switch httpMethod {
-	case "POST", "PUT":
-		if b.postBody != nil {
-			// Assume the body is to be marshalled to JSON
-			contentBody, err := json.Marshal(b.postBody)
-			if err != nil {
-				return nil, err
-			}
-			contentReader := bytes.NewReader(contentBody)
-			req, err = http.NewRequest(httpMethod, url, contentReader)
-			if err != nil {
-				return nil, err
-			}
-			req.Header.Set("Content-Type", "application/json")
-		} else if len(b.postFormParams) > 0 {
-			contentForm := b.postFormParams.Encode()
-			contentReader := strings.NewReader(contentForm)
-			if req, err = http.NewRequest(httpMethod, url, contentReader); err != nil {
-				return nil, err
-			}
-			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		} else if len(b.postMultiPartParam) > 0 {
-			contentBody := &bytes.Buffer{}
-			writer := multipart.NewWriter(contentBody)
-			for key, value := range b.postMultiPartParam {
-				if err := writer.WriteField(key, string(value)); err != nil {
-					return nil, err
-				}
-			}
-			if err = writer.Close(); err != nil {
-				return nil, err
-			}
-			if req, err = http.NewRequest(httpMethod, url, contentBody); err != nil {
-				return nil, err
-			}
-			req.Header.Set("Content-Type", "multipart/form-data")
-		}
-	case "GET", "DELETE":
-		req, err = http.NewRequest(httpMethod, url, nil)
-		if err != nil {
-			return nil, err
+	// Request builders may share a callback type, in which case it is declared once.
+	callbacks := make(map[string]*parse.ParseResult)
+	for _, r := range results {
+		if r.CallbackType == "" || r.AsyncResponse == nil {
+			continue
 		}
-		if len(b.queryParams) > 0 {
-			req.URL.RawQuery = b.queryParams.Encode()
+		if c, ok := callbacks[r.CallbackType]; ok {
+			if c.ResponseType != r.ResponseType {
+				return nil, fmt.Errorf("callback %s is declared with response types %s and %s", r.CallbackType, c.ResponseType, r.ResponseType)
+			}
+			continue
 		}
+		callbacks[r.CallbackType] = r
+		f.Callbacks = append(f.Callbacks, r)
 	}
-	req.Header.Set("Accept", "application/json")
-	for key, value := range b.headerParams {
-		req.Header.Set(key, value)
-	}
-	return req, nil
+
+	return render(fileTmpl, f)
 }
 
-{{ if and .ResponseType .SyncResponse }}
-func (b *{{ $.RequestType }}Impl) {{ $.SyncResponse | FunctionName }}() ({{ $.ResponseType }}, error) {
-	request, err := b.build()
+// GenerateMock generates a mock implementation of every request builder contained in
+// results. The mocks are written to a single file in the order they were parsed.
+func GenerateMock(results []*parse.ParseResult) ([]byte, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no request builder interfaces to generate")
+	}
+
+	// The requests of a service are mocked by the service mock
+	f, err := newFile(results, mockImports)
 	if err != nil {
 		return nil, err
 	}
-	request.URL.RawQuery = request.URL.Query().Encode()
-
-	restClient := restclient.GetClient()
-	if restClient == nil {
-		return nil, fmt.Errorf("A rest client has not been registered yet. You must call client.RegisterClient first")
+	var requests []*parse.ParseResult
+	for _, r := range f.Requests {
+		if r.ServiceType != "" {
+			continue
+		}
+		for _, method := range append([]*ast.Field{r.SyncResponse, r.AsyncResponse}, r.Params...) {
+			if method != nil && mockFields[method.Names[0].Name] {
+				return nil, fmt.Errorf("%s: method %s has the name of a field of the mock, which records the calls of the builder", r.RequestType, method.Names[0].Name)
+			}
+		}
+		requests = append(requests, r)
 	}
+	f.Requests = requests
+	return render(mockFileTmpl, f)
+}
 
-	if restClient.Debug() {
-		restclient.DebugRequest(request)
+// newFile returns the data of a file containing the requests with the imports. The
+// requests of every service are also grouped by their service, in the order the services
+// were parsed.
+func newFile(results []*parse.ParseResult, imports []importSpec) (file, error) {
+	f := file{
+		PackageName: results[0].PackageName,
+		Requests:    results,
 	}
-
-	response, err := restClient.HttpClient().Do(request)
+	imports, err := inputImports(imports, results)
 	if err != nil {
-		return nil, err
+		return f, err
+	}
+	for _, i := range imports {
+		if strings.Contains(strings.Split(i.Path, "/")[0], ".") {
+			f.Imports = append(f.Imports, i)
+		} else {
+			f.StdImports = append(f.StdImports, i)
+		}
+	}
+	services := make(map[string]*service)
+	for _, r := range results {
+		if r.ServiceType == "" {
+			continue
+		}
+		s, ok := services[r.ServiceType]
+		if !ok {
+			s = &service{ServiceType: r.ServiceType}
+			services[r.ServiceType] = s
+			f.Services = append(f.Services, s)
+		}
+		s.Requests = append(s.Requests, r)
 	}
+	return f, nil
+}
 
-	defer response.Body.Close()
-	if restClient.Debug() {
-		restclient.DebugResponse(response)
+// inputImports adds the imports of the input files which the parameter and response
+// types refer to, as in models.Photo, to the imports of a generated file. The name of an
+// import whose name is not known from its path is assumed to be a qualifier which is not
+// the name of any other import. Blank and dot imports are not added.
+func inputImports(imports []importSpec, results []*parse.ParseResult) ([]importSpec, error) {
+	paths := make(map[string]string)
+	for _, i := range imports {
+		paths[i.name()] = i.Path
+	}
+	imports = append([]importSpec(nil), imports...)
+
+	qualifiers := typeQualifiers(results)
+	var unknown []importSpec
+	for _, r := range results {
+		for _, spec := range r.Imports {
+			i := importSpec{}
+			i.Path, _ = strconv.Unquote(spec.Path.Value)
+			if spec.Name != nil {
+				i.Name = spec.Name.Name
+			}
+			name, known := importName(spec)
+			if !known {
+				if i.Name == "" {
+					unknown = append(unknown, i)
+				}
+				continue
+			}
+			if !qualifiers[name] {
+				continue
+			}
+			if other, ok := paths[name]; ok {
+				if other != i.Path {
+					return nil, fmt.Errorf("%s: package %s is imported as %s, which is the name of package %s", r.RequestType, i.Path, name, other)
+				}
+				continue
+			}
+			paths[name] = i.Path
+			imports = append(imports, i)
+		}
 	}
 
-	return New{{ $.ResponseType }}(response.Body)
+	for qualifier := range qualifiers {
+		if _, ok := paths[qualifier]; !ok {
+			added := make(map[importSpec]bool)
+			for _, i := range unknown {
+				if !added[i] {
+					added[i] = true
+					imports = append(imports, i)
+				}
+			}
+			break
+		}
+	}
+	return imports, nil
 }
-{{ end }}
 
-{{ if and .CallbackType .AsyncResponse }}
-func (b *{{ $.RequestType }}Impl) {{ $.AsyncResponse | FunctionName }}({{ ParamsList $.AsyncResponse.Type }}) {
-	if {{ ParamName $.AsyncResponse.Type false 0 }} != nil {
-		{{ ParamName $.AsyncResponse.Type false 0 }}.OnStart()
+// name returns the name the import is referred to by.
+func (i importSpec) name() string {
+	if i.Name != "" {
+		return i.Name
 	}
+	return path.Base(i.Path)
+}
 
-	go func(b *{{ $.RequestType }}Impl) {
-		response, err := b.{{ $.SyncResponse | FunctionName }}()
-
-		if {{ ParamName $.AsyncResponse.Type false 0 }} != nil {
-			if err != nil {
-				{{ ParamName $.AsyncResponse.Type false 0 }}.OnError(err.Error())
-			} else {
-				{{ ParamName $.AsyncResponse.Type false 0 }}.OnSuccess(response)
+// typeQualifiers returns the package names qualifying the parameter and response types
+// of the requests.
+func typeQualifiers(results []*parse.ParseResult) map[string]bool {
+	qualifiers := make(map[string]bool)
+	for _, r := range results {
+		for _, f := range append([]*ast.Field{r.SyncResponse, r.AsyncResponse}, r.Params...) {
+			if f == nil {
+				continue
 			}
+			ast.Inspect(f.Type, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if ident, ok := sel.X.(*ast.Ident); ok {
+						qualifiers[ident.Name] = true
+					}
+				}
+				return true
+			})
 		}
-	}(b)
+	}
+	return qualifiers
 }
-{{ end }}
-`))
+
+// render executes the template and formats the generated source.
+func render(t *template.Template, data interface{}) ([]byte, error) {
 	var buf bytes.Buffer
-	err := builderTemplate.Execute(&buf, r)
-	if err != nil {
-		log.Fatalf("Failed to generate template: %v", err)
-		return nil, err
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to generate template: %v", err)
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := formatSource(buf.Bytes())
 	if err != nil {
-		log.Fatalf("Failed to generate template: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to format generated source: %v", err)
 	}
 
 	return formatted, nil
 }
 
+// getReceiverName returns name as the receiver of the methods generated for a request or
+// a service, or their mocks, unless one of their parameters has the name. A number is
+// then appended so the receiver never collides with a parameter.
+// Example: b, or b2 for a method Limit(b int)
+func getReceiverName(name string, data interface{}) string {
+	var results []*parse.ParseResult
+	switch v := data.(type) {
+	case *parse.ParseResult:
+		results = []*parse.ParseResult{v}
+	case *service:
+		results = v.Requests
+	}
+	taken := make(map[string]bool)
+	for _, r := range results {
+		methods := append([]*ast.Field{r.SyncResponse, r.AsyncResponse}, r.Params...)
+		for _, method := range methods {
+			if method == nil {
+				continue
+			}
+			for _, param := range method.Type.(*ast.FuncType).Params.List {
+				for _, n := range param.Names {
+					taken[n.Name] = true
+				}
+			}
+		}
+	}
+	receiver := name
+	for i := 2; taken[receiver]; i++ {
+		receiver = fmt.Sprintf("%s%d", name, i)
+	}
+	return receiver
+}
+
 // getFunctionName returns the name of the function
-func getFunctionName(f *ast.Field) string {
-	return f.Names[0].Name
+func getFunctionName(f *ast.Field) (string, error) {
+	if len(f.Names) == 0 {
+		return "", fmt.Errorf("embedded interface %s is not a function", types.ExprString(f.Type))
+	}
+	return f.Names[0].Name, nil
+}
+
+// getAnnotationKey returns the key of the annotation in the field's comment, for example QUERY
+func getAnnotationKey(f *ast.Field) (string, error) {
+	if annotation, valid := parse.ExtractRequestAnnotation(f.Doc.Text()); valid {
+		return annotation.Key, nil
+	}
+	name, _ := getFunctionName(f)
+	return "", fmt.Errorf("%s must have a query, path, field, part, header or body annotation", name)
 }
 
 // getAnnotationValue returns the value represented by the annotation in the field's comment
-func getAnnotationValue(f *ast.Field) string {
+func getAnnotationValue(f *ast.Field) (string, error) {
 	comment := f.Doc.Text()
 	if annotation, valid := parse.ExtractRequestAnnotation(comment); valid {
-		return annotation.Value
+		return annotation.Value, nil
 	}
-	log.Fatalf("Must have query or path or field parameter defined.")
-	return ""
+	name, _ := getFunctionName(f)
+	return "", fmt.Errorf("%s must have a query, path, field, part, header or body annotation", name)
+}
+
+// getAnnotationArg returns the named argument of the annotation in the field's comment
+// or an empty string if the argument is absent
+func getAnnotationArg(f *ast.Field, name string) string {
+	annotation, _ := parse.ExtractRequestAnnotation(f.Doc.Text())
+	return annotation.Args[name]
+}
+
+// getAnnotationFlag returns the boolean named argument of the annotation in the field's
+// comment, which is false if the argument is absent
+func getAnnotationFlag(f *ast.Field, name string) bool {
+	flag, _ := strconv.ParseBool(getAnnotationArg(f, name))
+	return flag
 }
 
-// getParamName returns the name of the parameter in the field's argument list
-func getParamName(function *ast.FuncType, forceString bool, index int) string {
-	p := function.Params
-	if len(p.List) == 0 {
-		log.Fatalf("Function does not have any parameters")
-		return ""
+// getParamName returns the name of the parameter at index in the function's parameter
+// list. Parameters declared together, such as (a, b string), each have their own index.
+func getParamName(function *ast.FuncType, index int) (string, error) {
+	var names []string
+	for i, param := range function.Params.List {
+		if len(param.Names) == 0 {
+			return "", fmt.Errorf("parameter %d of type %s must be named", i, types.ExprString(param.Type))
+		}
+		for _, name := range param.Names {
+			names = append(names, name.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("function does not have any parameters")
 	}
 
-	if index >= len(p.List) {
-		log.Fatalf("Illegal parameter index %d. Number of parameters for function is %d", index, len(p.List))
-		return ""
+	if index >= len(names) {
+		return "", fmt.Errorf("illegal parameter index %d. Number of parameters for function is %d", index, len(names))
 	}
+	return names[index], nil
+}
 
-	param := p.List[index]
-	paramName := param.Names[0].Name
-	if forceString {
-		paramName = "fmt.Sprintf(\"%v\"," + paramName + ")"
+// getParamArg returns the value of the request parameter supplied by the function, which
+// is its parameter. The parameters of a function accepting several values are passed as
+// a slice so they are sent like the elements of a slice.
+// Example: tags, or []interface{}{a, b} for a function Tags(a, b string)
+func getParamArg(function *ast.FuncType) (string, error) {
+	var names []string
+	for i, param := range function.Params.List {
+		if len(param.Names) == 0 {
+			return "", fmt.Errorf("parameter %d of type %s must be named", i, types.ExprString(param.Type))
+		}
+		for _, name := range param.Names {
+			names = append(names, name.Name)
+		}
 	}
-	return paramName
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("function does not have any parameters")
+	case 1:
+		return names[0], nil
+	}
+	return "[]interface{}{" + strings.Join(names, ", ") + "}", nil
 }
 
 // getParamsList returns a comma separated list of parameter name, parameter type pairs
 // Example: size int8, name string, lat float64
-func getParamsList(function *ast.FuncType) string {
-	p := function.Params
-	var s string
-	for i := 0; i < len(p.List); i++ {
-		f := p.List[i]
-		s += fmt.Sprintf("%s %s", f.Names[0].Name, getParamType(f.Type))
-		if i != len(p.List)-1 {
-			s += ","
+// Parameters declared together keep their shared type, for example: a, b string
+func getParamsList(function *ast.FuncType) (string, error) {
+	var params []string
+	for i, param := range function.Params.List {
+		if len(param.Names) == 0 {
+			return "", fmt.Errorf("parameter %d of type %s must be named", i, types.ExprString(param.Type))
+		}
+		var names []string
+		for _, name := range param.Names {
+			names = append(names, name.Name)
+		}
+		params = append(params, strings.Join(names, ", ")+" "+getParamType(param.Type))
+	}
+	return strings.Join(params, ","), nil
+}
+
+// getArgsList returns a comma separated list of the parameter names of the function,
+// which passes its arguments on to a function of the same signature.
+// Example: ctx, id, tags...
+func getArgsList(function *ast.FuncType) (string, error) {
+	var args []string
+	for i, param := range function.Params.List {
+		if len(param.Names) == 0 {
+			return "", fmt.Errorf("parameter %d of type %s must be named", i, types.ExprString(param.Type))
+		}
+		for _, name := range param.Names {
+			args = append(args, name.Name)
+		}
+		if _, ok := param.Type.(*ast.Ellipsis); ok {
+			args[len(args)-1] += "..."
 		}
 	}
-	return s
+	return strings.Join(args, ", "), nil
 }
 
 // getParamType will return the parameter type
+// Every type expression of the Go grammar is supported, for example []string,
+// map[string][]int, ...int, func(int) (string, error), <-chan T or List[int].
 func getParamType(e ast.Expr) string {
-	switch v := e.(type) {
-	case *ast.Ident:
-		return v.Name
-	case *ast.StarExpr:
-		return "*" + getParamType(v.X)
-	case *ast.SelectorExpr:
-		return getParamType(v.X) + "." + getParamType(v.Sel)
-	default:
-		log.Fatalf("Unrecognized expression type: %v", e)
-		return ""
-	}
+	return types.ExprString(e)
 }
@@ -1,15 +1,58 @@
 package generate
 
 import (
+	"fmt"
 	"go/ast"
+	"go/importer"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"strings"
 	"testing"
 
 	"github.com/jsaund/gorest/parse"
 	"github.com/stretchr/testify/assert"
 )
 
+// parseSource parses the requests declared by the source of a file, which must be valid.
+func parseSource(t *testing.T, src string) []*parse.ParseResult {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "input.go", src, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results, err := parse.NewParserWithFileSet(fset, f, f.Name.Name).Parse()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return results
+}
+
+// sourceImporter imports the packages of the generated code from source. It is shared
+// by the tests as type checking net/http from source is slow.
+var sourceImporter = importer.ForCompiler(token.NewFileSet(), "source", nil)
+
+// compileSource type checks the generated files together with the source they were
+// generated from, which reports the compile errors of the generated code.
+func compileSource(t *testing.T, src string, generated ...[]byte) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "input.go", src, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	files := []*ast.File{f}
+	for i, data := range generated {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("generated%d.go", i), data, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: sourceImporter}
+	_, err = conf.Check(f.Name.Name, fset, files, nil)
+	assert.NoError(t, err)
+}
+
 func TestGenerateValid(t *testing.T) {
 	src := `package test
 		// @GET("/photos/{id}")
@@ -35,12 +78,10 @@ func TestGenerateValid(t *testing.T) {
 package test
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"mime/multipart"
 	"net/http"
-	"net/url"
+	neturl "net/url"
 	"strings"
 
 	"github.com/jsaund/gorest/restclient"
@@ -53,142 +94,149 @@ type GetPhotoDetailsCallback interface {
 }
 
 type GetPhotoDetailsRequestBuilderImpl struct {
-	pathSubstitutions  map[string]string
-	queryParams        url.Values
-	postFormParams     url.Values
-	postBody           interface{}
-	postMultiPartParam map[string][]byte
-	headerParams       map[string]string
+	client              restclient.Client
+	pathSubstitutions   map[string]string
+	queryParams         neturl.Values
+	encodedQueryParams  []string
+	postFormParams      neturl.Values
+	postBody            interface{}
+	postMultiPartParams []restclient.Part
+	headerParams        http.Header
+	dynamicURL          string
 }
 
 func NewGetPhotoDetailsRequestBuilder() GetPhotoDetailsRequestBuilder {
+	return NewGetPhotoDetailsRequestBuilderWithClient(nil)
+}
+
+// NewGetPhotoDetailsRequestBuilderWithClient creates a request builder which sends requests with client
+// instead of the registered client. A nil client uses the registered client.
+func NewGetPhotoDetailsRequestBuilderWithClient(client restclient.Client) GetPhotoDetailsRequestBuilder {
 	return &GetPhotoDetailsRequestBuilderImpl{
-		pathSubstitutions:  make(map[string]string),
-		queryParams:        url.Values{},
-		postFormParams:     url.Values{},
-		postMultiPartParam: make(map[string][]byte),
-		headerParams:       make(map[string]string),
+		client:            client,
+		pathSubstitutions: make(map[string]string),
+		queryParams:       neturl.Values{},
+		postFormParams:    neturl.Values{},
+		headerParams:      http.Header{},
 	}
 }
 
 func (b *GetPhotoDetailsRequestBuilderImpl) PhotoID(id string) GetPhotoDetailsRequestBuilder {
-	b.pathSubstitutions["id"] = fmt.Sprintf("%v", id)
+	if value, ok := restclient.ParamValue(id); ok {
+		b.pathSubstitutions["id"] = neturl.PathEscape(value)
+	} else {
+		delete(b.pathSubstitutions, "id")
+	}
 	return b
 }
 
 func (b *GetPhotoDetailsRequestBuilderImpl) ImageSize(size int) GetPhotoDetailsRequestBuilder {
-	b.queryParams.Add("image_size", fmt.Sprintf("%v", size))
+	for _, value := range restclient.ParamValues(size, false) {
+		b.queryParams.Add("image_size", value)
+	}
 	return b
 }
 
-func (b *GetPhotoDetailsRequestBuilderImpl) applyPathSubstituions(api string) string {
-	if len(b.pathSubstitutions) == 0 {
-		return api
-	}
-
-	for key, value := range b.pathSubstitutions {
+func (b *GetPhotoDetailsRequestBuilderImpl) applyPathSubstituions(api string) (string, error) {
+	for _, key := range []string{"id"} {
+		value, ok := b.pathSubstitutions[key]
+		if !ok {
+			return "", fmt.Errorf("path parameter %s of GetPhotoDetailsRequestBuilder has not been set", key)
+		}
 		api = strings.Replace(api, "{"+key+"}", value, -1)
 	}
-
-	return api
+	return api, nil
 }
 
-func (b *GetPhotoDetailsRequestBuilderImpl) build() (req *http.Request, err error) {
+func (b *GetPhotoDetailsRequestBuilderImpl) restClient() (restclient.Client, error) {
+	if b.client != nil {
+		return b.client, nil
+	}
 	restClient := restclient.GetClient()
 	if restClient == nil {
 		return nil, fmt.Errorf("A rest client has not been registered yet. You must call client.RegisterClient first")
 	}
-	url := restClient.BaseURL() + b.applyPathSubstituions("/photos/{id}")
+	return restClient, nil
+}
+
+func (b *GetPhotoDetailsRequestBuilderImpl) build(ctx context.Context, restClient restclient.Client, converter restclient.Converter) (req *http.Request, err error) {
+	endpoint, err := b.applyPathSubstituions("/photos/{id}")
+	if err != nil {
+		return nil, err
+	}
+	url, err := restclient.EndpointURL(restClient.BaseURL(), endpoint)
+	if err != nil {
+		return nil, err
+	}
 	httpMethod := "GET"
-	switch httpMethod {
-	case "POST", "PUT":
-		if b.postBody != nil {
-			// Assume the body is to be marshalled to JSON
-			contentBody, err := json.Marshal(b.postBody)
-			if err != nil {
-				return nil, err
-			}
-			contentReader := bytes.NewReader(contentBody)
-			req, err = http.NewRequest(httpMethod, url, contentReader)
-			if err != nil {
-				return nil, err
-			}
-			req.Header.Set("Content-Type", "application/json")
-		} else if len(b.postFormParams) > 0 {
-			contentForm := b.postFormParams.Encode()
-			contentReader := strings.NewReader(contentForm)
-			if req, err = http.NewRequest(httpMethod, url, contentReader); err != nil {
-				return nil, err
-			}
-			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		} else if len(b.postMultiPartParam) > 0 {
-			contentBody := &bytes.Buffer{}
-			writer := multipart.NewWriter(contentBody)
-			for key, value := range b.postMultiPartParam {
-				if err := writer.WriteField(key, string(value)); err != nil {
-					return nil, err
-				}
-			}
-			if err = writer.Close(); err != nil {
-				return nil, err
-			}
-			if req, err = http.NewRequest(httpMethod, url, contentBody); err != nil {
-				return nil, err
-			}
-			req.Header.Set("Content-Type", "multipart/form-data")
-		}
-	case "GET", "DELETE":
-		req, err = http.NewRequest(httpMethod, url, nil)
-		if err != nil {
-			return nil, err
+	req, err = http.NewRequestWithContext(ctx, httpMethod, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.queryParams) > 0 || len(b.encodedQueryParams) > 0 {
+		// Query parameters are added to those declared by the endpoint
+		query := req.URL.Query()
+		for key, values := range b.queryParams {
+			query[key] = append(query[key], values...)
 		}
-		if len(b.queryParams) > 0 {
-			req.URL.RawQuery = b.queryParams.Encode()
+		req.URL.RawQuery = query.Encode()
+		// Encoded query parameters are sent as they are
+		for _, param := range b.encodedQueryParams {
+			if req.URL.RawQuery != "" {
+				req.URL.RawQuery += "&"
+			}
+			req.URL.RawQuery += param
 		}
 	}
-	req.Header.Set("Accept", "application/json")
-	for key, value := range b.headerParams {
-		req.Header.Set(key, value)
+	req.Header.Set("Accept", converter.ContentType())
+	// Header keys are unique so the order they are copied in does not matter. The values
+	// are copied so the request does not share them with the builder
+	for key, values := range b.headerParams {
+		req.Header[key] = append([]string(nil), values...)
 	}
 	return req, nil
 }
 
-func (b *GetPhotoDetailsRequestBuilderImpl) Run() (GetPhotoDetailsResponse, error) {
-	request, err := b.build()
+func (b *GetPhotoDetailsRequestBuilderImpl) do(ctx context.Context) (result GetPhotoDetailsResponse, err error) {
+	restClient, err := b.restClient()
 	if err != nil {
-		return nil, err
+		return result, err
 	}
-	request.URL.RawQuery = request.URL.Query().Encode()
 
-	restClient := restclient.GetClient()
-	if restClient == nil {
-		return nil, fmt.Errorf("A rest client has not been registered yet. You must call client.RegisterClient first")
+	converter, err := restclient.ResolveConverter(restClient, "")
+	if err != nil {
+		return result, err
 	}
 
-	if restClient.Debug() {
-		restclient.DebugRequest(request)
+	request, err := b.build(ctx, restClient, converter)
+	if err != nil {
+		return result, err
 	}
 
-	response, err := restClient.HttpClient().Do(request)
+	response, err := restclient.Do(restClient, request)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
-
 	defer response.Body.Close()
-	if restClient.Debug() {
-		restclient.DebugResponse(response)
+
+	if !restclient.IsSuccess(response.StatusCode) {
+		return result, restclient.NewHTTPError(response)
 	}
 
 	return NewGetPhotoDetailsResponse(response.Body)
 }
 
+func (b *GetPhotoDetailsRequestBuilderImpl) Run() (GetPhotoDetailsResponse, error) {
+	return b.do(context.Background())
+}
+
 func (b *GetPhotoDetailsRequestBuilderImpl) RunAsync(callback GetPhotoDetailsCallback) {
 	if callback != nil {
 		callback.OnStart()
 	}
 
 	go func(b *GetPhotoDetailsRequestBuilderImpl) {
-		response, err := b.Run()
+		response, err := b.do(context.Background())
 
 		if callback != nil {
 			if err != nil {
@@ -200,17 +248,1101 @@ func (b *GetPhotoDetailsRequestBuilderImpl) RunAsync(callback GetPhotoDetailsCal
 	}(b)
 }
 `
+	result := parseSource(t, src)
+
+	data, err := Generate(result)
+	assert.NoError(t, err)
+
+	assert.Equal(t, output, string(data))
+}
+
+func TestGenerateMultipleRequests(t *testing.T) {
+	src := `package test
+		// @GET("/photos/{id}")
+		type GetPhotoDetailsRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) GetPhotoDetailsRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+
+			// @ASYNC("PhotoCallback")
+			RunAsync(callback PhotoCallback)
+		}
+
+		// @PUT("/photos/{id}")
+		type UpdatePhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) UpdatePhotoRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+
+			// @ASYNC("PhotoCallback")
+			RunAsync(callback PhotoCallback)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	assert.NoError(t, err)
+
+	generated := string(data)
+	assert.Equal(t, 1, strings.Count(generated, "package test"))
+	assert.Equal(t, 1, strings.Count(generated, "import ("))
+	assert.Equal(t, 1, strings.Count(generated, "type PhotoCallback interface"))
+	assert.Contains(t, generated, "func NewGetPhotoDetailsRequestBuilder() GetPhotoDetailsRequestBuilder")
+	assert.Contains(t, generated, "func NewUpdatePhotoRequestBuilder() UpdatePhotoRequestBuilder")
+	assert.True(t, strings.Index(generated, "GetPhotoDetailsRequestBuilderImpl") < strings.Index(generated, "UpdatePhotoRequestBuilderImpl"))
+}
+
+func TestGenerateBody(t *testing.T) {
+	src := `package test
+		// @POST("/photos")
+		type PostPhotoRequestBuilder interface {
+			// @BODY("photo")
+			PhotoMetadata(metadata Metadata) PostPhotoRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	assert.NoError(t, err)
+
+	generated := string(data)
+	assert.Contains(t, generated, `func (b *PostPhotoRequestBuilderImpl) PhotoMetadata(metadata Metadata) PostPhotoRequestBuilder {
+	b.postBody = metadata
+	return b
+}`)
+	assert.Contains(t, generated, "contentBody, err := converter.Marshal(b.postBody)")
+}
+
+func TestGenerateHttpMethods(t *testing.T) {
+	src := `package test
+		// @HEAD("/photos/{id}")
+		type HeadPhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) HeadPhotoRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+
+		// @PATCH("/photos/{id}")
+		type PatchPhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) PatchPhotoRequestBuilder
+
+			// @BODY("photo")
+			PhotoMetadata(metadata Metadata) PatchPhotoRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+
+		// @HTTP(method="PROPFIND", path="/files/{id}", hasBody=true)
+		type PropfindRequestBuilder interface {
+			// @PATH("id")
+			FileID(id string) PropfindRequestBuilder
+
+			// @BODY("query")
+			Query(query PropQuery) PropfindRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	generated := string(data)
+	build := func(requestType string) string {
+		start := strings.Index(generated, "func (b *"+requestType+"Impl) build(")
+		if start < 0 {
+			return ""
+		}
+		return generated[start : start+strings.Index(generated[start:], "\n}\n")]
+	}
+
+	// Requests without a body are created without one and send the query
+	head := build("HeadPhotoRequestBuilder")
+	assert.Contains(t, head, `httpMethod := "HEAD"`)
+	assert.Contains(t, head, `
+	req, err = http.NewRequestWithContext(ctx, httpMethod, url, nil)`)
+	assert.Contains(t, head, "req.URL.RawQuery = query.Encode()")
+	assert.NotContains(t, head, "b.postBody")
+
+	// Requests with a body send the body
+	patch := build("PatchPhotoRequestBuilder")
+	assert.Contains(t, patch, `httpMethod := "PATCH"`)
+	assert.Contains(t, patch, "contentBody, err := converter.Marshal(b.postBody)")
+	propfind := build("PropfindRequestBuilder")
+	assert.Contains(t, propfind, `httpMethod := "PROPFIND"`)
+	assert.Contains(t, propfind, "contentBody, err := converter.Marshal(b.postBody)")
+}
+
+func TestGenerateQuery(t *testing.T) {
+	src := `package test
+		// @POST("/photos?sort=date")
+		type PostPhotosRequestBuilder interface {
+			// @QUERY("tags")
+			Tags(tags []string) PostPhotosRequestBuilder
+
+			// @QUERY("ids", format="csv")
+			IDs(ids []int) PostPhotosRequestBuilder
+
+			// @QUERY("page", omitempty=true)
+			Page(page *int) PostPhotosRequestBuilder
+
+			// @FIELD("labels", format="csv", omitempty=true)
+			Labels(labels []string) PostPhotosRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	generated := string(data)
+	assert.Contains(t, generated, `func (b *PostPhotosRequestBuilderImpl) Tags(tags []string) PostPhotosRequestBuilder {
+	for _, value := range restclient.ParamValues(tags, false) {
+		b.queryParams.Add("tags", value)
+	}
+	return b
+}`)
+	assert.Contains(t, generated, `func (b *PostPhotosRequestBuilderImpl) IDs(ids []int) PostPhotosRequestBuilder {
+	if values := restclient.ParamValues(ids, false); len(values) > 0 {
+		b.queryParams.Add("ids", strings.Join(values, ","))
+	}
+	return b
+}`)
+	assert.Contains(t, generated, `func (b *PostPhotosRequestBuilderImpl) Page(page *int) PostPhotosRequestBuilder {
+	for _, value := range restclient.ParamValues(page, true) {
+		b.queryParams.Add("page", value)
+	}
+	return b
+}`)
+	assert.Contains(t, generated, `func (b *PostPhotosRequestBuilderImpl) Labels(labels []string) PostPhotosRequestBuilder {
+	if values := restclient.ParamValues(labels, true); len(values) > 0 {
+		b.postFormParams.Add("labels", strings.Join(values, ","))
+	}
+	return b
+}`)
+
+	// The query is sent with requests which have a body
+	assert.Contains(t, generated, `	if len(b.queryParams) > 0 || len(b.encodedQueryParams) > 0 {
+		// Query parameters are added to those declared by the endpoint
+		query := req.URL.Query()`)
+}
+
+func TestGenerateEncoded(t *testing.T) {
+	src := `package test
+		// @GET("/files/{path}/{id}")
+		type GetFileRequestBuilder interface {
+			// @PATH("id")
+			ID(id int) GetFileRequestBuilder
+
+			// @PATH("path", encoded=true)
+			Path(path string) GetFileRequestBuilder
+
+			// @QUERY("filter", encoded=true)
+			Filter(filter string) GetFileRequestBuilder
+
+			// @SYNC("FileResponse")
+			Run() (FileResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Path values are escaped unless they are already encoded
+	generated := string(data)
+	assert.Contains(t, generated, `func (b *GetFileRequestBuilderImpl) ID(id int) GetFileRequestBuilder {
+	if value, ok := restclient.ParamValue(id); ok {
+		b.pathSubstitutions["id"] = neturl.PathEscape(value)
+	} else {
+		delete(b.pathSubstitutions, "id")
+	}
+	return b
+}`)
+	assert.Contains(t, generated, `func (b *GetFileRequestBuilderImpl) Path(path string) GetFileRequestBuilder {
+	if value, ok := restclient.ParamValue(path); ok {
+		b.pathSubstitutions["path"] = value
+	} else {
+		delete(b.pathSubstitutions, "path")
+	}
+	return b
+}`)
+	assert.Contains(t, generated, `func (b *GetFileRequestBuilderImpl) Filter(filter string) GetFileRequestBuilder {
+	for _, value := range restclient.ParamValues(filter, false) {
+		b.encodedQueryParams = append(b.encodedQueryParams, "filter="+value)
+	}
+	return b
+}`)
+}
+
+func TestGenerateTypedParams(t *testing.T) {
+	src := `package test
+		import "time"
+
+		// @GET("/events/{day}")
+		type GetEventsRequestBuilder interface {
+			// @PATH("day")
+			Day(day time.Time) GetEventsRequestBuilder
+
+			// @HEADER("X-Limit")
+			Limit(limit *int) GetEventsRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Values are formatted by their type and nil pointers are not sent
+	generated := string(data)
+	assert.Contains(t, generated, "\t\"time\"\n")
+	assert.Contains(t, generated, `func (b *GetEventsRequestBuilderImpl) Day(day time.Time) GetEventsRequestBuilder {
+	if value, ok := restclient.ParamValue(day); ok {
+		b.pathSubstitutions["day"] = neturl.PathEscape(value)
+	} else {
+		delete(b.pathSubstitutions, "day")
+	}
+	return b
+}`)
+	assert.Contains(t, generated, `func (b *GetEventsRequestBuilderImpl) Limit(limit *int) GetEventsRequestBuilder {
+	if value, ok := restclient.ParamValue(limit); ok {
+		b.headerParams.Add("X-Limit", value)
+	} else {
+		b.headerParams.Del("X-Limit")
+	}
+	return b
+}`)
+	assert.NotContains(t, generated, "fmt.Sprintf")
+}
+
+func TestGenerateParamTypes(t *testing.T) {
+	src := `package test
+
+		// @GET("/search")
+		type SearchRequestBuilder interface {
+			// @QUERY("tag")
+			Tags(tags ...string) SearchRequestBuilder
+
+			// @QUERY("filter")
+			Filters(filters map[string][]int) SearchRequestBuilder
+
+			// @SYNC("SearchResponse")
+			Run(ctx context.Context) (SearchResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	generated := string(data)
+	assert.Contains(t, generated, "func (b *SearchRequestBuilderImpl) Tags(tags ...string) SearchRequestBuilder {")
+	assert.Contains(t, generated, "func (b *SearchRequestBuilderImpl) Filters(filters map[string][]int) SearchRequestBuilder {")
+}
+
+func TestGetParamName(t *testing.T) {
+	src := `package main
+		func grouped(ctx context.Context, a, b string, c int) {
+		}
+		`
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, "input.go", src, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		return
+	}
+	function := f.Decls[0].(*ast.FuncDecl).Type
+
+	for i, expected := range []string{"ctx", "a", "b", "c"} {
+		name, err := getParamName(function, i)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, name)
+	}
+	_, err = getParamName(function, 4)
+	assert.Error(t, err)
+}
+
+func TestGenerateParamNamedURL(t *testing.T) {
+	src := `package test
+
+		import "net/url"
+
+		// @GET("/links/{url}")
+		type GetLinkRequestBuilder interface {
+			// @PATH("url")
+			URL(url string) GetLinkRequestBuilder
+
+			// @URL()
+			Next(url *url.URL) GetLinkRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// net/url is not shadowed by the parameter and parameter types still refer to it as url
+	generated := string(data)
+	assert.Contains(t, generated, `func (b *GetLinkRequestBuilderImpl) URL(url string) GetLinkRequestBuilder {
+	if value, ok := restclient.ParamValue(url); ok {
+		b.pathSubstitutions["url"] = neturl.PathEscape(value)
+	} else {`)
+	assert.Contains(t, generated, `func (b *GetLinkRequestBuilderImpl) Next(url *url.URL) GetLinkRequestBuilder {`)
+	compileSource(t, src, data)
+}
+
+func TestGenerateImports(t *testing.T) {
+	src := `package test
+
+		import (
+			"encoding/json"
+			"math/big"
+			"os/exec"
+
+			"gopkg.in/yaml.v3"
+		)
+
+		var _, _ = exec.Command, yaml.Marshal
+
+		type Photo struct{}
+
+		// @GET("/photos")
+		type ListPhotosRequestBuilder interface {
+			// @QUERY("min")
+			Min(min json.Number) ListPhotosRequestBuilder
+
+			// @QUERY("max")
+			Max(max *big.Int) ListPhotosRequestBuilder
+
+			// @SYNC("Photo")
+			Run() (Photo, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+	mock, err := GenerateMock(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Only the imports of the input the types refer to are added
+	assert.Contains(t, string(data), `import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	neturl "net/url"
+
+	"github.com/jsaund/gorest/restclient"
+)`)
+	compileSource(t, src, data, mock)
+}
+
+func TestGenerateQualifiedImports(t *testing.T) {
+	src := `package test
+
+		import (
+			"example.com/api/go-models"
+			photos "example.com/api/photos"
+		)
+
+		// @GET("/photos/{id}")
+		type GetPhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id photos.ID) GetPhotoRequestBuilder
+
+			// @SYNC("*models.Photo")
+			Run() (*models.Photo, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The import whose name is not known from its path is assumed to be models
+	assert.Contains(t, string(data), `
+	"example.com/api/go-models"
+	photos "example.com/api/photos"
+	"github.com/jsaund/gorest/restclient"
+)`)
+	// The constructor of the response is declared in the package of the response
+	assert.Contains(t, string(data), "return models.NewPhoto(response.Body)")
+
+	src = `package test
+
+		import "example.com/api/http"
+
+		// @GET("/photos")
+		type ListPhotosRequestBuilder interface {
+			// @HEADER("X-Status")
+			Status(status http.Status) ListPhotosRequestBuilder
+		}
+		`
+	_, err = Generate(parseSource(t, src))
+	assert.EqualError(t, err, "ListPhotosRequestBuilder: package example.com/api/http is imported as http, which is the name of package net/http")
+}
+
+func TestGenerateURL(t *testing.T) {
+	src := `package test
+
+		import "net/url"
+
+		// @GET("/photos/{id}")
+		type GetPhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) GetPhotoRequestBuilder
+
+			// @URL()
+			URL(u *url.URL) GetPhotoRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The URL replaces the endpoint, whose path parameters are then not required
+	generated := string(data)
+	assert.Contains(t, generated, `func (b *GetPhotoRequestBuilderImpl) URL(u *url.URL) GetPhotoRequestBuilder {
+	b.dynamicURL, _ = restclient.ParamValue(u)
+	return b
+}`)
+	assert.Contains(t, generated, `	var url string
+	if b.dynamicURL != "" {
+		// The URL parameter replaces the endpoint
+		url, err = restclient.ResolveURL(restClient.BaseURL(), b.dynamicURL)
+	} else {
+		var endpoint string
+		if endpoint, err = b.applyPathSubstituions("/photos/{id}"); err != nil {
+			return nil, err
+		}
+		url, err = restclient.EndpointURL(restClient.BaseURL(), endpoint)
+	}`)
+
+	mock, err := GenerateMock(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(mock), "	m.DynamicURL, _ = restclient.ParamValue(u)")
+	compileSource(t, src, data, mock)
+}
+
+func TestGenerateHeaders(t *testing.T) {
+	src := `package test
+
+		// @GET("/repos")
+		// @HEADERS("Accept: application/vnd.github.v3+json", "X-Api-Version: 2", "X-Api-Version: 3")
+		type ListReposRequestBuilder interface {
+			// @HEADER("X-Api-Version")
+			Version(version string) ListReposRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Header values are added to the static headers, which replace the Accept header
+	generated := string(data)
+	assert.Contains(t, generated, `		b.headerParams.Add("X-Api-Version", value)`)
+	assert.Contains(t, generated, `	req.Header.Set("Accept", converter.ContentType())
+	// The values supplied by the header methods are copied in to the static headers
+	headers := http.Header{
+		"Accept":        {"application/vnd.github.v3+json"},
+		"X-Api-Version": {"2", "3"},
+	}
+	for key, values := range b.headerParams {
+		headers[key] = append(headers[key], values...)
+	}
+	// Header keys are unique so the order they are copied in does not matter
+	for key, values := range headers {
+		req.Header[key] = values
+	}`)
+
+	// Static headers are written as Go strings
+	src = `package test
+
+		// @GET("/files")
+		// @HEADERS("X-Path: C:\data\photos", "X-Filter: *.jpg")
+		type ListFilesRequestBuilder interface {
+		}
+		`
+	data, err = Generate(parseSource(t, src))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(data), `		"X-Filter": {"*.jpg"},
+		"X-Path":   {"C:\\data\\photos"},`)
+	compileSource(t, src, data)
+}
+
+func TestGenerateMultipart(t *testing.T) {
+	src := `package test
+		// @POST("/photos")
+		type UploadPhotoRequestBuilder interface {
+			// @PART("photo", filename="photo.jpg", contentType="image/jpeg")
+			Photo(photo io.Reader) UploadPhotoRequestBuilder
+
+			// @PART("caption")
+			Caption(caption string) UploadPhotoRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	generated := string(data)
+	assert.Contains(t, generated, `func (b *UploadPhotoRequestBuilderImpl) Photo(photo io.Reader) UploadPhotoRequestBuilder {
+	b.postMultiPartParams = append(b.postMultiPartParams, restclient.NewParts("photo", photo, "photo.jpg", "image/jpeg")...)
+	return b
+}`)
+	assert.Contains(t, generated, `func (b *UploadPhotoRequestBuilderImpl) Caption(caption string) UploadPhotoRequestBuilder {
+	b.postMultiPartParams = append(b.postMultiPartParams, restclient.NewParts("caption", caption, "", "")...)
+	return b
+}`)
+	// Methods are generated in declaration order
+	assert.True(t, strings.Index(generated, ") Photo(") < strings.Index(generated, ") Caption("))
+	assert.Contains(t, generated, `	} else if len(b.postMultiPartParams) > 0 {
+		if req, err = restclient.NewMultipartRequest(ctx, httpMethod, url, b.postMultiPartParams); err != nil {
+			return nil, err
+		}`)
+}
+
+func TestGenerateMultipartFields(t *testing.T) {
+	src := `package test
+
+		import "io"
+
+		// @POST("/photos")
+		type UploadPhotoRequestBuilder interface {
+			// @FIELD("caption")
+			Caption(caption string) UploadPhotoRequestBuilder
+
+			// @PART("photo")
+			Photo(photo io.Reader) UploadPhotoRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The form fields are sent as fields of the multipart body
+	assert.Contains(t, string(data), `	} else if len(b.postFormParams) > 0 || len(b.postMultiPartParams) > 0 {
+		// The form fields are sent as fields of the multipart body
+		parts := append(restclient.FormParts(b.postFormParams), b.postMultiPartParams...)
+		if req, err = restclient.NewMultipartRequest(ctx, httpMethod, url, parts); err != nil {
+			return nil, err
+		}
+	} else {`)
+	assert.NotContains(t, string(data), "application/x-www-form-urlencoded")
+	compileSource(t, src, data)
+}
+
+func TestGenerateInvalidParam(t *testing.T) {
+	src := `package test
+		// @GET("/photos")
+		type GetPhotosRequestBuilder interface {
+			// @QUERY("size")
+			Size(int) GetPhotosRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	// The generator reports the problem instead of exiting
+	_, err := Generate(results)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "parameter 0 of type int must be named")
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	src := `package test
+		// @GET("/photos/{id}")
+		type GetPhotoDetailsRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) GetPhotoDetailsRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run(ctx context.Context) (PhotoResponse, error)
+
+			// @ASYNC("PhotoCallback")
+			RunAsync(ctx context.Context, callback PhotoCallback)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
 	assert.NoError(t, err)
 
-	p := parse.NewParser(f, "test")
-	result := p.Parse()
+	generated := string(data)
+	assert.Contains(t, generated, `func (b *GetPhotoDetailsRequestBuilderImpl) Run(ctx context.Context) (PhotoResponse, error) {
+	return b.do(ctx)
+}`)
+	assert.Contains(t, generated, `func (b *GetPhotoDetailsRequestBuilderImpl) RunAsync(ctx context.Context, callback PhotoCallback) {
+	if callback != nil {
+		callback.OnStart()
+	}
 
-	data, err := Generate(result)
+	go func(b *GetPhotoDetailsRequestBuilderImpl) {
+		response, err := b.do(ctx)
+`)
+}
+
+func TestGenerateSuccessCodes(t *testing.T) {
+	src := `package test
+		// @DELETE("/photos/{id}")
+		// @SUCCESS("200, 204")
+		type DeletePhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) DeletePhotoRequestBuilder
+
+			// @SYNC("DeletePhotoResponse")
+			Run() (DeletePhotoResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
 	assert.NoError(t, err)
 
-	assert.Equal(t, output, string(data))
+	assert.Contains(t, string(data), `	if !restclient.IsSuccess(response.StatusCode, 200, 204) {
+		return result, restclient.NewHTTPError(response)
+	}`)
+}
+
+func TestGenerateClientAndRetry(t *testing.T) {
+	src := `package test
+		// @GET("/invoices")
+		// @CLIENT("billing")
+		// @RETRY("5")
+		type GetInvoicesRequestBuilder interface {
+			// @SYNC("InvoicesResponse")
+			Run() (InvoicesResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(data), `func (b *GetInvoicesRequestBuilderImpl) restClient() (restclient.Client, error) {
+	if b.client != nil {
+		return b.client, nil
+	}
+	restClient := restclient.GetNamedClient("billing")
+	if restClient == nil {`)
+	assert.Contains(t, string(data), `response, err := restclient.DoWithRetry(restClient, request, restClient.RetryPolicy().ForEndpoint(5))`)
+}
+
+func TestGenerateConverter(t *testing.T) {
+	src := `package test
+		// @POST("/invoices")
+		// @CONVERTER("xml")
+		type CreateInvoiceRequestBuilder interface {
+			// @BODY("invoice")
+			Invoice(invoice Invoice) CreateInvoiceRequestBuilder
+
+			// @SYNC("InvoiceResponse")
+			Run() (InvoiceResponse, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	generated := string(data)
+	assert.Contains(t, generated, `	converter, err := restclient.ResolveConverter(restClient, "xml")
+	if err != nil {
+		return result, err
+	}
+
+	request, err := b.build(ctx, restClient, converter)`)
+	assert.Contains(t, generated, `		contentBody, err := converter.Marshal(b.postBody)`)
+	assert.Contains(t, generated, `		req.Header.Set("Content-Type", converter.ContentType())`)
+	assert.Contains(t, generated, `	req.Header.Set("Accept", converter.ContentType())`)
+}
+
+func TestGenerateResponseDecoding(t *testing.T) {
+	var testCases = []struct {
+		response     string
+		declarations string
+		result       string
+	}{
+		// Struct responses are decoded by the converter
+		{"Photo", "type Photo struct{ ID int }", "err = restclient.DecodeResponse(converter, response, &result)"},
+		{"*Photo", "type Photo struct{ ID int }", "err = restclient.DecodeResponse(converter, response, &result)"},
+		// A constructor takes precedence
+		{"Photo", "type Photo struct{ ID int }\nfunc NewPhoto(r io.Reader) (Photo, error) { return Photo{}, nil }", "return NewPhoto(response.Body)"},
+		{"*Photo", "type Photo struct{ ID int }\nfunc NewPhoto(r io.Reader) (*Photo, error) { return nil, nil }", "return NewPhoto(response.Body)"},
+		// Other responses are created by their constructor
+		{"Photo", "type Photo interface{ ID() int }", "return NewPhoto(response.Body)"},
+		{"Photo", "", "return NewPhoto(response.Body)"},
+		{"*Photo", "", "return NewPhoto(response.Body)"},
+	}
+
+	for _, tc := range testCases {
+		src := `package test
+		// @GET("/photos")
+		type GetPhotoRequestBuilder interface {
+			// @SYNC("` + tc.response + `")
+			Run() (` + tc.response + `, error)
+		}
+		` + tc.declarations
+		results := parseSource(t, src)
+		data, err := Generate(results)
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(data), tc.result, "%s %s", tc.response, tc.declarations)
+		}
+	}
+}
+
+func TestGenerateService(t *testing.T) {
+	src := `package test
+		import "context"
+
+		// @CLIENT("photos")
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(ctx context.Context,
+				// @PATH("id")
+				id string,
+				// @QUERY("tag")
+				tags ...string,
+			) (*Photo, error)
+
+			// @POST("/photos")
+			CreatePhoto(/* @BODY("photo") */ photo Photo) (*Photo, error)
+
+			// @GET("/photos")
+			ListPhotos(/* @URL() */ url string) ([]Photo, error)
+		}
+
+		type Photo struct {
+			ID string
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Every method of the service builds and sends its own request
+	generated := string(data)
+	assert.Contains(t, generated, "type photoServiceGetPhotoImpl struct {")
+	assert.Contains(t, generated, "type photoServiceCreatePhotoImpl struct {")
+	assert.Contains(t, generated, `restclient.GetNamedClient("photos")`)
+	assert.Contains(t, generated, `func NewPhotoServiceWithClient(client restclient.Client) PhotoService {
+	return &PhotoServiceImpl{
+		client: client,
+	}
+}`)
+	assert.Contains(t, generated, `func (s *PhotoServiceImpl) GetPhoto(ctx context.Context, id string, tags ...string) (*Photo, error) {
+	b := &photoServiceGetPhotoImpl{
+		client:            s.client,
+		pathSubstitutions: make(map[string]string),
+		queryParams:       neturl.Values{},
+		postFormParams:    neturl.Values{},
+		headerParams:      http.Header{},
+	}
+	if value, ok := restclient.ParamValue(id); ok {
+		b.pathSubstitutions["id"] = neturl.PathEscape(value)
+	} else {
+		delete(b.pathSubstitutions, "id")
+	}
+	for _, value := range restclient.ParamValues(tags, false) {
+		b.queryParams.Add("tag", value)
+	}
+	return b.do(ctx)
+}`)
+	assert.Contains(t, generated, `func (s *PhotoServiceImpl) CreatePhoto(photo Photo) (*Photo, error) {`)
+	assert.Contains(t, generated, "	b.postBody = photo\n	return b.do(context.Background())\n}")
+
+	// A slice response is decoded and a parameter named url does not shadow net/url
+	assert.Contains(t, generated, `func (s *PhotoServiceImpl) ListPhotos(url string) ([]Photo, error) {`)
+	assert.Contains(t, generated, "	b.dynamicURL, _ = restclient.ParamValue(url)\n	return b.do(context.Background())\n}")
+	assert.Contains(t, generated, "func (b *photoServiceListPhotosImpl) do(ctx context.Context) (result []Photo, err error) {")
+	assert.NotContains(t, generated, "New[]Photo")
+
+	// The request builders of the methods are not part of the API
+	assert.NotContains(t, generated, "func NewphotoService")
+	assert.NotContains(t, generated, ") GetPhoto(ctx context.Context) (")
+
+	data, err = GenerateMock(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+	generated = string(data)
+	assert.NotContains(t, generated, "photoServiceGetPhoto")
+	assert.Contains(t, generated, "GetPhotoFunc    func(ctx context.Context, id string, tags ...string) (*Photo, error)")
+	assert.Contains(t, generated, `func (m *PhotoServiceMock) GetPhoto(ctx context.Context, id string, tags ...string) (*Photo, error) {
+	m.Calls = append(m.Calls, "GetPhoto")
+	if m.GetPhotoFunc == nil {
+		return *new(*Photo), nil
+	}
+	return m.GetPhotoFunc(ctx, id, tags...)
+}`)
+}
+
+func TestGenerateMock(t *testing.T) {
+	src := `package test
+		// @GET("/photos/{id}")
+		type GetPhotoDetailsRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) GetPhotoDetailsRequestBuilder
+
+			// @QUERY("image_size")
+			ImageSize(size int) GetPhotoDetailsRequestBuilder
+
+			// @HEADER("User-Agent")
+			UserAgent(agent string) GetPhotoDetailsRequestBuilder
+
+			// @SYNC("GetPhotoDetailsResponse")
+			Run() (GetPhotoDetailsResponse, error)
+
+			// @ASYNC("GetPhotoDetailsCallback")
+			RunAsync(callback GetPhotoDetailsCallback)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := GenerateMock(results)
+	assert.NoError(t, err)
+
+	generated := string(data)
+	assert.Contains(t, generated, `import (
+	"net/http"
+	neturl "net/url"
+
+	"github.com/jsaund/gorest/restclient"
+)`)
+	assert.Contains(t, generated, `func (m *GetPhotoDetailsRequestBuilderMock) PhotoID(id string) GetPhotoDetailsRequestBuilder {
+	m.Calls = append(m.Calls, "PhotoID")
+	if value, ok := restclient.ParamValue(id); ok {
+		m.PathSubstitutions["id"] = value
+	} else {
+		delete(m.PathSubstitutions, "id")
+	}
+	return m
+}`)
+	assert.Contains(t, generated, `func (m *GetPhotoDetailsRequestBuilderMock) ImageSize(size int) GetPhotoDetailsRequestBuilder {
+	m.Calls = append(m.Calls, "ImageSize")
+	for _, value := range restclient.ParamValues(size, false) {
+		m.QueryParams.Add("image_size", value)
+	}
+	return m
+}`)
+	assert.Contains(t, generated, `	if value, ok := restclient.ParamValue(agent); ok {
+		m.HeaderParams.Add("User-Agent", value)
+	} else {
+		m.HeaderParams.Del("User-Agent")
+	}`)
+	assert.Contains(t, generated, `func (m *GetPhotoDetailsRequestBuilderMock) Run() (GetPhotoDetailsResponse, error) {
+	m.Calls = append(m.Calls, "Run")
+	return m.Response, m.Err
+}`)
+	assert.Contains(t, generated, `func (m *GetPhotoDetailsRequestBuilderMock) RunAsync(callback GetPhotoDetailsCallback) {`)
+	assert.NotContains(t, generated, "type GetPhotoDetailsCallback interface")
+}
+
+func TestGenerateMockQuery(t *testing.T) {
+	src := `package test
+		// @GET("/photos")
+		type ListPhotosRequestBuilder interface {
+			// @QUERY("tags", format="csv")
+			Tags(tags []string) ListPhotosRequestBuilder
+
+			// @QUERY("filter", encoded=true)
+			Filter(filter string) ListPhotosRequestBuilder
+
+			// @QUERY("ids", format="csv", encoded=true)
+			IDs(ids []int) ListPhotosRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := GenerateMock(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The query is recorded as the builder sends it
+	generated := string(data)
+	assert.Contains(t, generated, `	if values := restclient.ParamValues(tags, false); len(values) > 0 {
+		m.QueryParams.Add("tags", strings.Join(values, ","))
+	}`)
+	assert.Contains(t, generated, `	for _, value := range restclient.ParamValues(filter, false) {
+		m.EncodedQueryParams = append(m.EncodedQueryParams, "filter="+value)
+	}`)
+	assert.Contains(t, generated, `	if values := restclient.ParamValues(ids, false); len(values) > 0 {
+		m.EncodedQueryParams = append(m.EncodedQueryParams, "ids="+strings.Join(values, ","))
+	}`)
+}
+
+func TestGenerateMockReceiver(t *testing.T) {
+	src := `package test
+		// @GET("/photos")
+		type ListPhotosRequestBuilder interface {
+			// @QUERY("limit")
+			Limit(m int) ListPhotosRequestBuilder
+
+			// @QUERY("offset")
+			Offset(m2 int) ListPhotosRequestBuilder
+		}
+
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(/* @PATH("id") */ m string) (Photo, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := GenerateMock(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The receiver does not collide with the parameters of any method
+	generated := string(data)
+	assert.Contains(t, generated, `func (m3 *ListPhotosRequestBuilderMock) Limit(m int) ListPhotosRequestBuilder {
+	m3.Calls = append(m3.Calls, "Limit")`)
+	assert.Contains(t, generated, `func (m2 *PhotoServiceMock) GetPhoto(m string) (Photo, error) {
+	m2.Calls = append(m2.Calls, "GetPhoto")`)
+}
+
+func TestGenerateReceiver(t *testing.T) {
+	src := `package test
+
+		type Photo struct{}
+
+		// @GET("/photos/{id}")
+		type GetPhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(b string) GetPhotoRequestBuilder
+
+			// @QUERY("size")
+			Size(b2 int) GetPhotoRequestBuilder
+
+			// @SYNC("Photo")
+			Run() (Photo, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The receiver does not collide with the parameters of any method
+	assert.Contains(t, string(data), `func (b3 *GetPhotoRequestBuilderImpl) PhotoID(b string) GetPhotoRequestBuilder {
+	if value, ok := restclient.ParamValue(b); ok {
+		b3.pathSubstitutions["id"] = neturl.PathEscape(value)`)
+	compileSource(t, src, data)
+}
+
+func TestGenerateServiceReceiver(t *testing.T) {
+	src := `package test
+
+		type Photo struct{}
+
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(/* @PATH("id") */ s string, /* @QUERY("size") */ b int) (Photo, error)
+
+			// @GET("/albums/{id}")
+			GetAlbum(/* @PATH("id") */ b string) (Photo, error)
+		}
+		`
+	results := parseSource(t, src)
+
+	data, err := Generate(results)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The receiver and the request builder of every method do not collide with its parameters
+	generated := string(data)
+	assert.Contains(t, generated, `func (s2 *PhotoServiceImpl) GetPhoto(s string, b int) (Photo, error) {
+	b2 := &photoServiceGetPhotoImpl{
+		client:            s2.client,`)
+	assert.Contains(t, generated, `func (s2 *PhotoServiceImpl) GetAlbum(b string) (Photo, error) {
+	b2 := &photoServiceGetAlbumImpl{`)
+	compileSource(t, src, data)
+}
+
+func TestGenerateMockFieldName(t *testing.T) {
+	src := `package test
+		// @GET("/photos")
+		type ListPhotosRequestBuilder interface {
+			// @QUERY("calls")
+			Calls(calls int) ListPhotosRequestBuilder
+		}
+		`
+	results := parseSource(t, src)
+
+	_, err := GenerateMock(results)
+	assert.EqualError(t, err, "ListPhotosRequestBuilder: method Calls has the name of a field of the mock, which records the calls of the builder")
+}
+
+func TestGenerateNoRequests(t *testing.T) {
+	_, err := Generate(nil)
+	assert.Error(t, err)
+
+	_, err = GenerateMock(nil)
+	assert.Error(t, err)
 }
 
 func TestGetParamsList(t *testing.T) {
@@ -246,42 +1378,19 @@ func TestGetParamsList(t *testing.T) {
 			`,
 			"arg1 string,arg2 int,arg3 bool,arg4 string",
 		},
-	}
-
-	for _, tc := range testCases {
-		fset := token.NewFileSet()
-		f, err := parser.ParseFile(fset, "input.go", tc.input, 0)
-		assert.NoError(t, err)
-		paramList := getParamsList(f.Decls[0].(*ast.FuncDecl).Type)
-		assert.Equal(t, tc.output, paramList)
-	}
-}
-
-func TestParamType(t *testing.T) {
-	var testCases = []struct {
-		input  string
-		output string
-	}{
-		{
-			`package main
-			func one(a string) {
-			}
-			`,
-			"string",
-		},
 		{
 			`package main
-			func two(b *Pointer) {
+			func sharedType(a, b string, c int) {
 			}
 			`,
-			"*Pointer",
+			"a, b string,c int",
 		},
 		{
 			`package main
-			func three(b *some.Pointer) {
+			func variadic(ctx context.Context, ids ...int) {
 			}
 			`,
-			"*some.Pointer",
+			"ctx context.Context,ids ...int",
 		},
 	}
 
@@ -289,8 +1398,59 @@ func TestParamType(t *testing.T) {
 		fset := token.NewFileSet()
 		f, err := parser.ParseFile(fset, "input.go", tc.input, 0)
 		assert.NoError(t, err)
-		params := f.Decls[0].(*ast.FuncDecl).Type.Params
-		paramType := getParamType(params.List[0].Type)
-		assert.Equal(t, tc.output, paramType)
+		paramList, err := getParamsList(f.Decls[0].(*ast.FuncDecl).Type)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.output, paramList)
+	}
+}
+
+func TestParamType(t *testing.T) {
+	var testCases = []struct {
+		params string
+		arg    string
+	}{
+		{"tags []string", "tags"},
+		{"ids [4]int", "ids"},
+		{"ids ...int", "ids"},
+		{"labels map[string][]string", "labels"},
+		{"f func(int, string) (bool, error)", "f"},
+		{"c <-chan *Photo", "c"},
+		{"p *Pair[string, map[int]bool]", "p"},
+		{"v interface{ String() string }", "v"},
+		{"x, y string", "[]interface{}{x, y}"},
+		{"x string, y ...int", "[]interface{}{x, y}"},
+	}
+
+	for _, tc := range testCases {
+		src := `package test
+
+			type Photo struct{}
+
+			type Pair[K comparable, V any] struct{}
+
+			// @GET("/photos")
+			type ListPhotosRequestBuilder interface {
+				// @QUERY("q")
+				Query(` + tc.params + `) ListPhotosRequestBuilder
+
+				// @SYNC("Photo")
+				Run() (Photo, error)
+			}
+			`
+		results := parseSource(t, src)
+
+		data, err := Generate(results)
+		if !assert.NoError(t, err, tc.params) {
+			continue
+		}
+		mock, err := GenerateMock(results)
+		if !assert.NoError(t, err, tc.params) {
+			continue
+		}
+
+		// Several parameters are sent like the elements of a slice
+		assert.Contains(t, string(data), `func (b *ListPhotosRequestBuilderImpl) Query(`+tc.params+`) ListPhotosRequestBuilder {
+	for _, value := range restclient.ParamValues(`+tc.arg+`, false) {`)
+		compileSource(t, src, data, mock)
 	}
 }
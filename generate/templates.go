@@ -0,0 +1,574 @@
+package generate
+
+// fileTemplate is the layout of a generated file. All request builders parsed from
+// the input share the package clause, the imports and the callback declarations.
+// The imports are those of builderImports followed by the imports of the input.
+const fileTemplate = `/*
+* CODE GENERATED AUTOMATICALLY WITH GOREST (github.com/jsaund/gorest)
+* THIS FILE SHOULD NOT BE EDITED BY HAND
+*/
+
+package {{ .PackageName }}
+
+import (
+{{- range .StdImports }}
+	{{ . }}
+{{- end }}
+{{ range .Imports }}
+	{{ . }}
+{{- end }}
+)
+
+{{ range .Callbacks }}
+type {{ .CallbackType }} interface {
+	OnStart()
+	OnError(reason string)
+	OnSuccess(response {{ .ResponseType }})
+}
+{{ end }}
+
+{{ range .Requests }}
+{{ template "builder" . }}
+{{ end }}
+
+{{ range .Services }}
+{{ template "service" . }}
+{{ end }}
+`
+
+// builderTemplate is the implementation of a single request builder interface.
+const builderTemplate = `{{ define "builder" }}
+{{- $b := ReceiverName "b" . }}
+type {{ .RequestType }}Impl struct {
+	client              restclient.Client
+	pathSubstitutions   map[string]string
+	queryParams         neturl.Values
+	encodedQueryParams  []string
+	postFormParams      neturl.Values
+	postBody            interface{}
+	postMultiPartParams []restclient.Part
+	headerParams        http.Header
+	dynamicURL          string
+}
+
+{{ if not .ServiceType }}
+func New{{ .RequestType }}() {{ .RequestType }} {
+	return New{{ .RequestType }}WithClient(nil)
+}
+
+// New{{ .RequestType }}WithClient creates a request builder which sends requests with client
+// instead of the registered client. A nil client uses the registered client.
+func New{{ .RequestType }}WithClient(client restclient.Client) {{ .RequestType }} {
+	return &{{ .RequestType }}Impl{
+		client:            client,
+		pathSubstitutions: make(map[string]string),
+		queryParams:       neturl.Values{},
+		postFormParams:    neturl.Values{},
+		headerParams:      http.Header{},
+	}
+}
+
+{{ range $value := .Params }}
+func ({{ $b }} *{{ $.RequestType }}Impl) {{ FunctionName $value }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
+	{{- template "param" (Param $b $value) }}
+	return {{ $b }}
+}
+{{ end }}
+{{ end }}
+
+func ({{ $b }} *{{ .RequestType }}Impl) applyPathSubstituions(api string) (string, error) {
+{{- if .PathPlaceholders }}
+	for _, key := range []string{ {{- range $i, $key := .PathPlaceholders }}{{ if $i }}, {{ end }}"{{ $key }}"{{ end -}} } {
+		value, ok := {{ $b }}.pathSubstitutions[key]
+		if !ok {
+			return "", fmt.Errorf("path parameter %s of {{ .RequestType }} has not been set", key)
+		}
+		api = strings.Replace(api, "{" + key + "}", value, -1)
+	}
+{{- end }}
+	return api, nil
+}
+
+func ({{ $b }} *{{ .RequestType }}Impl) restClient() (restclient.Client, error) {
+	if {{ $b }}.client != nil {
+		return {{ $b }}.client, nil
+	}
+{{- if .ClientName }}
+	restClient := restclient.GetNamedClient("{{ .ClientName }}")
+	if restClient == nil {
+		return nil, fmt.Errorf("A rest client named {{ .ClientName }} has not been registered yet. You must call restclient.RegisterNamedClient first")
+	}
+{{- else }}
+	restClient := restclient.GetClient()
+	if restClient == nil {
+		return nil, fmt.Errorf("A rest client has not been registered yet. You must call client.RegisterClient first")
+	}
+{{- end }}
+	return restClient, nil
+}
+
+func ({{ $b }} *{{ .RequestType }}Impl) build(ctx context.Context, restClient restclient.Client, converter restclient.Converter) (req *http.Request, err error) {
+{{- if .URLParam }}
+	var url string
+	if {{ $b }}.dynamicURL != "" {
+		// The URL parameter replaces the endpoint
+		url, err = restclient.ResolveURL(restClient.BaseURL(), {{ $b }}.dynamicURL)
+	} else {
+		var endpoint string
+		if endpoint, err = {{ $b }}.applyPathSubstituions("{{ .ApiEndpoint }}"); err != nil {
+			return nil, err
+		}
+		url, err = restclient.EndpointURL(restClient.BaseURL(), endpoint)
+	}
+	if err != nil {
+		return nil, err
+	}
+{{- else }}
+	endpoint, err := {{ $b }}.applyPathSubstituions("{{ .ApiEndpoint }}")
+	if err != nil {
+		return nil, err
+	}
+	url, err := restclient.EndpointURL(restClient.BaseURL(), endpoint)
+	if err != nil {
+		return nil, err
+	}
+{{- end }}
+	httpMethod := "{{ .HttpMethod }}"
+{{- if .HasBody }}
+	if {{ $b }}.postBody != nil {
+		contentBody, err := converter.Marshal({{ $b }}.postBody)
+		if err != nil {
+			return nil, err
+		}
+		contentReader := bytes.NewReader(contentBody)
+		req, err = http.NewRequestWithContext(ctx, httpMethod, url, contentReader)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", converter.ContentType())
+{{- if and .PostFormParams .PostMultiPartParams }}
+	} else if len({{ $b }}.postFormParams) > 0 || len({{ $b }}.postMultiPartParams) > 0 {
+		// The form fields are sent as fields of the multipart body
+		parts := append(restclient.FormParts({{ $b }}.postFormParams), {{ $b }}.postMultiPartParams...)
+		if req, err = restclient.NewMultipartRequest(ctx, httpMethod, url, parts); err != nil {
+			return nil, err
+		}
+{{- else }}
+	} else if len({{ $b }}.postFormParams) > 0 {
+		contentForm := {{ $b }}.postFormParams.Encode()
+		contentReader := strings.NewReader(contentForm)
+		if req, err = http.NewRequestWithContext(ctx, httpMethod, url, contentReader); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else if len({{ $b }}.postMultiPartParams) > 0 {
+		if req, err = restclient.NewMultipartRequest(ctx, httpMethod, url, {{ $b }}.postMultiPartParams); err != nil {
+			return nil, err
+		}
+{{- end }}
+	} else {
+		if req, err = http.NewRequestWithContext(ctx, httpMethod, url, nil); err != nil {
+			return nil, err
+		}
+	}
+{{- else }}
+	req, err = http.NewRequestWithContext(ctx, httpMethod, url, nil)
+	if err != nil {
+		return nil, err
+	}
+{{- end }}
+	if len({{ $b }}.queryParams) > 0 || len({{ $b }}.encodedQueryParams) > 0 {
+		// Query parameters are added to those declared by the endpoint
+		query := req.URL.Query()
+		for key, values := range {{ $b }}.queryParams {
+			query[key] = append(query[key], values...)
+		}
+		req.URL.RawQuery = query.Encode()
+		// Encoded query parameters are sent as they are
+		for _, param := range {{ $b }}.encodedQueryParams {
+			if req.URL.RawQuery != "" {
+				req.URL.RawQuery += "&"
+			}
+			req.URL.RawQuery += param
+		}
+	}
+	req.Header.Set("Accept", converter.ContentType())
+{{- if .Headers }}
+	// The values supplied by the header methods are copied in to the static headers
+	headers := http.Header{
+{{- range $key, $values := .Headers }}
+		{{ Quote $key }}: { {{- range $i, $v := $values }}{{ if $i }}, {{ end }}{{ Quote $v }}{{ end -}} },
+{{- end }}
+	}
+	for key, values := range {{ $b }}.headerParams {
+		headers[key] = append(headers[key], values...)
+	}
+	// Header keys are unique so the order they are copied in does not matter
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+{{- else }}
+	// Header keys are unique so the order they are copied in does not matter. The values
+	// are copied so the request does not share them with the builder
+	for key, values := range {{ $b }}.headerParams {
+		req.Header[key] = append([]string(nil), values...)
+	}
+{{- end }}
+	return req, nil
+}
+
+{{ if and .ResponseType .SyncResponse }}
+func ({{ $b }} *{{ $.RequestType }}Impl) do(ctx context.Context) (result {{ $.ResponseType }}, err error) {
+	restClient, err := {{ $b }}.restClient()
+	if err != nil {
+		return result, err
+	}
+
+	converter, err := restclient.ResolveConverter(restClient, "{{ $.ConverterName }}")
+	if err != nil {
+		return result, err
+	}
+
+	request, err := {{ $b }}.build(ctx, restClient, converter)
+	if err != nil {
+		return result, err
+	}
+
+	{{ if $.RetryAttempts -}}
+	response, err := restclient.DoWithRetry(restClient, request, restClient.RetryPolicy().ForEndpoint({{ $.RetryAttempts }}))
+	{{- else -}}
+	response, err := restclient.Do(restClient, request)
+	{{- end }}
+	if err != nil {
+		return result, err
+	}
+	defer response.Body.Close()
+
+	if !restclient.IsSuccess(response.StatusCode{{ range $.SuccessCodes }}, {{ . }}{{ end }}) {
+		return result, restclient.NewHTTPError(response)
+	}
+
+{{ if $.DecodeResponse }}
+	err = restclient.DecodeResponse(converter, response, &result)
+	return result, err
+{{- else }}
+	return {{ $.ResponseConstructor }}(response.Body)
+{{- end }}
+}
+
+{{ if $.ServiceType }}
+{{ else if $.SyncContext }}
+func ({{ $b }} *{{ $.RequestType }}Impl) {{ $.SyncResponse | FunctionName }}({{ ParamsList $.SyncResponse.Type }}) ({{ $.ResponseType }}, error) {
+	return {{ $b }}.do({{ ParamName $.SyncResponse.Type 0 }})
+}
+{{ else }}
+func ({{ $b }} *{{ $.RequestType }}Impl) {{ $.SyncResponse | FunctionName }}() ({{ $.ResponseType }}, error) {
+	return {{ $b }}.do(context.Background())
+}
+{{ end }}
+{{ end }}
+
+{{ if and .CallbackType .AsyncResponse .SyncResponse }}
+{{ $callback := ParamName $.AsyncResponse.Type 0 }}
+{{ $ctx := "context.Background()" }}
+{{ if $.AsyncContext }}
+	{{ $callback = ParamName $.AsyncResponse.Type 1 }}
+	{{ $ctx = ParamName $.AsyncResponse.Type 0 }}
+{{ end }}
+func ({{ $b }} *{{ $.RequestType }}Impl) {{ $.AsyncResponse | FunctionName }}({{ ParamsList $.AsyncResponse.Type }}) {
+	if {{ $callback }} != nil {
+		{{ $callback }}.OnStart()
+	}
+
+	go func({{ $b }} *{{ $.RequestType }}Impl) {
+		response, err := {{ $b }}.do({{ $ctx }})
+
+		if {{ $callback }} != nil {
+			if err != nil {
+				{{ $callback }}.OnError(err.Error())
+			} else {
+				{{ $callback }}.OnSuccess(response)
+			}
+		}
+	}({{ $b }})
+}
+{{ end }}
+{{ end }}`
+
+// paramTemplate adds the parameter of a request builder method, or of a service method,
+// to the request builder named by the Builder of its param.
+const paramTemplate = `{{ define "param" }}
+{{- $b := .Builder }}
+{{- $value := .Field }}
+{{- $kind := AnnotationKey $value }}
+{{- if eq $kind "PATH" }}
+	if value, ok := restclient.ParamValue({{ ParamArg $value.Type }}); ok {
+{{- if AnnotationFlag $value "encoded" }}
+		{{ $b }}.pathSubstitutions["{{ AnnotationValue $value }}"] = value
+{{- else }}
+		{{ $b }}.pathSubstitutions["{{ AnnotationValue $value }}"] = neturl.PathEscape(value)
+{{- end }}
+	} else {
+		delete({{ $b }}.pathSubstitutions, "{{ AnnotationValue $value }}")
+	}
+{{- else if eq $kind "QUERY" }}
+{{- $encoded := AnnotationFlag $value "encoded" }}
+{{- if eq (AnnotationArg $value "format") "csv" }}
+	if values := restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}); len(values) > 0 {
+{{- if $encoded }}
+		{{ $b }}.encodedQueryParams = append({{ $b }}.encodedQueryParams, "{{ AnnotationValue $value }}=" + strings.Join(values, ","))
+{{- else }}
+		{{ $b }}.queryParams.Add("{{ AnnotationValue $value }}", strings.Join(values, ","))
+{{- end }}
+	}
+{{- else }}
+	for _, value := range restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}) {
+{{- if $encoded }}
+		{{ $b }}.encodedQueryParams = append({{ $b }}.encodedQueryParams, "{{ AnnotationValue $value }}=" + value)
+{{- else }}
+		{{ $b }}.queryParams.Add("{{ AnnotationValue $value }}", value)
+{{- end }}
+	}
+{{- end }}
+{{- else if eq $kind "FIELD" }}
+{{- if eq (AnnotationArg $value "format") "csv" }}
+	if values := restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}); len(values) > 0 {
+		{{ $b }}.postFormParams.Add("{{ AnnotationValue $value }}", strings.Join(values, ","))
+	}
+{{- else }}
+	for _, value := range restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}) {
+		{{ $b }}.postFormParams.Add("{{ AnnotationValue $value }}", value)
+	}
+{{- end }}
+{{- else if eq $kind "BODY" }}
+	{{ $b }}.postBody = {{ ParamArg $value.Type }}
+{{- else if eq $kind "HEADER" }}
+	if value, ok := restclient.ParamValue({{ ParamArg $value.Type }}); ok {
+		{{ $b }}.headerParams.Add("{{ AnnotationValue $value }}", value)
+	} else {
+		{{ $b }}.headerParams.Del("{{ AnnotationValue $value }}")
+	}
+{{- else if eq $kind "URL" }}
+	{{ $b }}.dynamicURL, _ = restclient.ParamValue({{ ParamArg $value.Type }})
+{{- else if eq $kind "PART" }}
+	{{ $b }}.postMultiPartParams = append({{ $b }}.postMultiPartParams, restclient.NewParts("{{ AnnotationValue $value }}", {{ ParamArg $value.Type }}, "{{ AnnotationArg $value "filename" }}", "{{ AnnotationArg $value "contentType" }}")...)
+{{- end }}
+{{- end }}`
+
+// serviceTemplate is the implementation of a service interface. Every method builds its
+// request with the request builder implementation of the method.
+const serviceTemplate = `{{ define "service" }}
+{{- $s := ReceiverName "s" . }}
+type {{ .ServiceType }}Impl struct {
+	client restclient.Client
+}
+
+func New{{ .ServiceType }}() {{ .ServiceType }} {
+	return New{{ .ServiceType }}WithClient(nil)
+}
+
+// New{{ .ServiceType }}WithClient creates a service which sends requests with client
+// instead of the registered client. A nil client uses the registered client.
+func New{{ .ServiceType }}WithClient(client restclient.Client) {{ .ServiceType }} {
+	return &{{ .ServiceType }}Impl{
+		client: client,
+	}
+}
+
+{{ range .Requests }}
+{{- $b := ReceiverName "b" . }}
+func ({{ $s }} *{{ $.ServiceType }}Impl) {{ .SyncResponse | FunctionName }}({{ ParamsList .SyncResponse.Type }}) ({{ .ResponseType }}, error) {
+	{{ $b }} := &{{ .RequestType }}Impl{
+		client:            {{ $s }}.client,
+		pathSubstitutions: make(map[string]string),
+		queryParams:       neturl.Values{},
+		postFormParams:    neturl.Values{},
+		headerParams:      http.Header{},
+	}
+{{- range $value := .Params }}
+	{{- template "param" (Param $b $value) }}
+{{- end }}
+{{- if .SyncContext }}
+	return {{ $b }}.do({{ ParamName .SyncResponse.Type 0 }})
+{{- else }}
+	return {{ $b }}.do(context.Background())
+{{- end }}
+}
+{{ end }}
+{{ end }}`
+
+// mockFileTemplate is the layout of a generated file of mocks, whose imports are those
+// of mockImports followed by the imports of the input.
+const mockFileTemplate = `/*
+* CODE GENERATED AUTOMATICALLY WITH GOREST (github.com/jsaund/gorest)
+* THIS FILE SHOULD NOT BE EDITED BY HAND
+*/
+
+package {{ .PackageName }}
+
+import (
+{{- range .StdImports }}
+	{{ . }}
+{{- end }}
+{{ range .Imports }}
+	{{ . }}
+{{- end }}
+)
+
+{{ range .Requests }}
+{{ template "mock" . }}
+{{ end }}
+
+{{ range .Services }}
+{{ template "serviceMock" . }}
+{{ end }}
+`
+
+// mockTemplate is the mock implementation of a single request builder interface.
+const mockTemplate = `{{ define "mock" }}
+{{- $m := ReceiverName "m" . }}
+// {{ .RequestType }}Mock is a mock implementation of {{ .RequestType }}.
+// Every call to the builder is recorded{{ if .SyncResponse }} and the request returns Response or Err{{ end }}.
+type {{ .RequestType }}Mock struct {
+	// Calls contains the name of every method called, in order
+	Calls               []string
+	PathSubstitutions   map[string]string
+	QueryParams         neturl.Values
+	EncodedQueryParams  []string
+	PostFormParams      neturl.Values
+	PostBody            interface{}
+	PostMultiPartParams map[string]interface{}
+	HeaderParams        http.Header
+{{- if .URLParam }}
+	DynamicURL          string
+{{- end }}
+{{- if .SyncResponse }}
+	Response            {{ .ResponseType }}
+	Err                 error
+{{- end }}
+}
+
+func New{{ .RequestType }}Mock() *{{ .RequestType }}Mock {
+	return &{{ .RequestType }}Mock{
+		PathSubstitutions:   make(map[string]string),
+		QueryParams:         neturl.Values{},
+		PostFormParams:      neturl.Values{},
+		PostMultiPartParams: make(map[string]interface{}),
+		HeaderParams:        http.Header{},
+	}
+}
+
+{{ range $value := .Params }}
+{{- $kind := AnnotationKey $value }}
+func ({{ $m }} *{{ $.RequestType }}Mock) {{ FunctionName $value }}({{ ParamsList $value.Type }}) {{ $.RequestType }} {
+	{{ $m }}.Calls = append({{ $m }}.Calls, "{{ FunctionName $value }}")
+{{- if eq $kind "PATH" }}
+	if value, ok := restclient.ParamValue({{ ParamArg $value.Type }}); ok {
+		{{ $m }}.PathSubstitutions["{{ AnnotationValue $value }}"] = value
+	} else {
+		delete({{ $m }}.PathSubstitutions, "{{ AnnotationValue $value }}")
+	}
+{{- else if eq $kind "QUERY" }}
+{{- $encoded := AnnotationFlag $value "encoded" }}
+{{- if eq (AnnotationArg $value "format") "csv" }}
+	if values := restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}); len(values) > 0 {
+{{- if $encoded }}
+		{{ $m }}.EncodedQueryParams = append({{ $m }}.EncodedQueryParams, "{{ AnnotationValue $value }}=" + strings.Join(values, ","))
+{{- else }}
+		{{ $m }}.QueryParams.Add("{{ AnnotationValue $value }}", strings.Join(values, ","))
+{{- end }}
+	}
+{{- else }}
+	for _, value := range restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}) {
+{{- if $encoded }}
+		{{ $m }}.EncodedQueryParams = append({{ $m }}.EncodedQueryParams, "{{ AnnotationValue $value }}=" + value)
+{{- else }}
+		{{ $m }}.QueryParams.Add("{{ AnnotationValue $value }}", value)
+{{- end }}
+	}
+{{- end }}
+{{- else if eq $kind "FIELD" }}
+{{- if eq (AnnotationArg $value "format") "csv" }}
+	if values := restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}); len(values) > 0 {
+		{{ $m }}.PostFormParams.Add("{{ AnnotationValue $value }}", strings.Join(values, ","))
+	}
+{{- else }}
+	for _, value := range restclient.ParamValues({{ ParamArg $value.Type }}, {{ AnnotationFlag $value "omitempty" }}) {
+		{{ $m }}.PostFormParams.Add("{{ AnnotationValue $value }}", value)
+	}
+{{- end }}
+{{- else if eq $kind "BODY" }}
+	{{ $m }}.PostBody = {{ ParamArg $value.Type }}
+{{- else if eq $kind "HEADER" }}
+	if value, ok := restclient.ParamValue({{ ParamArg $value.Type }}); ok {
+		{{ $m }}.HeaderParams.Add("{{ AnnotationValue $value }}", value)
+	} else {
+		{{ $m }}.HeaderParams.Del("{{ AnnotationValue $value }}")
+	}
+{{- else if eq $kind "URL" }}
+	{{ $m }}.DynamicURL, _ = restclient.ParamValue({{ ParamArg $value.Type }})
+{{- else if eq $kind "PART" }}
+	{{ $m }}.PostMultiPartParams["{{ AnnotationValue $value }}"] = {{ ParamArg $value.Type }}
+{{- end }}
+	return {{ $m }}
+}
+{{ end }}
+
+{{ if .SyncResponse }}
+func ({{ $m }} *{{ $.RequestType }}Mock) {{ $.SyncResponse | FunctionName }}({{ ParamsList $.SyncResponse.Type }}) ({{ $.ResponseType }}, error) {
+	{{ $m }}.Calls = append({{ $m }}.Calls, "{{ $.SyncResponse | FunctionName }}")
+	return {{ $m }}.Response, {{ $m }}.Err
+}
+{{ end }}
+
+{{ if and .AsyncResponse .SyncResponse }}
+{{ $callback := ParamName $.AsyncResponse.Type 0 }}
+{{ if $.AsyncContext }}
+	{{ $callback = ParamName $.AsyncResponse.Type 1 }}
+{{ end }}
+// {{ $.AsyncResponse | FunctionName }} calls the callback before returning.
+func ({{ $m }} *{{ $.RequestType }}Mock) {{ $.AsyncResponse | FunctionName }}({{ ParamsList $.AsyncResponse.Type }}) {
+	{{ $m }}.Calls = append({{ $m }}.Calls, "{{ $.AsyncResponse | FunctionName }}")
+	if {{ $callback }} == nil {
+		return
+	}
+
+	{{ $callback }}.OnStart()
+	if {{ $m }}.Err != nil {
+		{{ $callback }}.OnError({{ $m }}.Err.Error())
+	} else {
+		{{ $callback }}.OnSuccess({{ $m }}.Response)
+	}
+}
+{{ end }}
+{{ end }}`
+
+// serviceMockTemplate is the mock implementation of a service interface.
+const serviceMockTemplate = `{{ define "serviceMock" }}
+{{- $m := ReceiverName "m" . }}
+// {{ .ServiceType }}Mock is a mock implementation of {{ .ServiceType }}.
+// Every call is recorded and answered by the func of the method, a method without a func
+// returns the zero value of its response.
+type {{ .ServiceType }}Mock struct {
+	// Calls contains the name of every method called, in order
+	Calls []string
+{{- range .Requests }}
+	{{ .SyncResponse | FunctionName }}Func func({{ ParamsList .SyncResponse.Type }}) ({{ .ResponseType }}, error)
+{{- end }}
+}
+
+func New{{ .ServiceType }}Mock() *{{ .ServiceType }}Mock {
+	return &{{ .ServiceType }}Mock{}
+}
+
+{{ range .Requests }}
+{{- $name := FunctionName .SyncResponse }}
+func ({{ $m }} *{{ $.ServiceType }}Mock) {{ $name }}({{ ParamsList .SyncResponse.Type }}) ({{ .ResponseType }}, error) {
+	{{ $m }}.Calls = append({{ $m }}.Calls, "{{ $name }}")
+	if {{ $m }}.{{ $name }}Func == nil {
+		return *new({{ .ResponseType }}), nil
+	}
+	return {{ $m }}.{{ $name }}Func({{ ArgsList .SyncResponse.Type }})
+}
+{{ end }}
+{{ end }}`
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
 	"go/token"
 	"io/ioutil"
 	"log"
@@ -18,9 +19,22 @@ var (
 	input  = flag.String("input", "", "name of input file containing REST API to generate (if absent then Stdin is used)")
 	output = flag.String("output", "", "name of output file containing generated API request and response implementation")
 	pkg    = flag.String("pkg", "", "name of output file package (should be the same as input package)")
+	mock   = flag.String("mock", "", "name of output file containing generated mock implementations of the request builders (optional)")
+	dir    = flag.String("dir", "", "directory of the package containing REST API to generate (all files of the package are parsed with full type information and input is ignored)")
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "openapi":
+			exportOpenAPI(os.Args[2:])
+			return
+		case "import-openapi":
+			importOpenAPI(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Parse()
 
 	if *output == "" {
@@ -29,33 +43,59 @@ func main() {
 		os.Exit(1)
 	}
 
-	if *pkg == "" {
+	if *pkg == "" && *dir == "" {
 		flag.Usage()
 		fmt.Fprintln(os.Stderr, "Expects valid package name")
 		os.Exit(1)
 	}
 
-	var file *ast.File
-	fileset := token.NewFileSet()
-
-	if *input != "" {
-		f, err := parser.ParseFile(fileset, *input, nil, parser.ParseComments)
+	var parseResults []*parse.ParseResult
+	if *dir != "" {
+		results, err := parse.ParsePackage(*dir)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to parse input filename. Is input filename %s valid?\n", *input)
+			printParseError(fmt.Sprintf("Failed to parse REST API definition in package %s:", *dir), err)
 			os.Exit(1)
 		}
-		file = f
+		if *pkg != "" {
+			for _, r := range results {
+				r.PackageName = *pkg
+			}
+		}
+		parseResults = results
 	} else {
-		f, err := parser.ParseFile(fileset, "", os.Stdin, parser.ParseComments)
+		var file *ast.File
+		fileset := token.NewFileSet()
+
+		if *input != "" {
+			f, err := parser.ParseFile(fileset, *input, nil, parser.ParseComments)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to parse input filename. Is input filename %s valid?\n", *input)
+				os.Exit(1)
+			}
+			file = f
+		} else {
+			f, err := parser.ParseFile(fileset, "", os.Stdin, parser.ParseComments)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Failed to parse input file. Is source input valid?")
+				os.Exit(1)
+			}
+			file = f
+		}
+
+		results, err := parseAST(fileset, file, *pkg)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Failed to parse input file. Is source input valid?")
+			printParseError("Failed to parse REST API definition:", err)
 			os.Exit(1)
 		}
-		file = f
+		parseResults = results
+	}
+
+	if len(parseResults) == 0 {
+		fmt.Fprintln(os.Stderr, "No request builder interfaces found. Is the interface annotated with an HTTP method?")
+		os.Exit(1)
 	}
 
-	parseResult := parseAST(file, *pkg)
-	buf, err := generateBuilder(parseResult)
+	buf, err := generateBuilder(parseResults)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to generate REST API implementation. %s\n", err)
 		os.Exit(1)
@@ -66,20 +106,48 @@ func main() {
 	}
 
 	fmt.Println("Generated source written to file " + *output)
+
+	if *mock == "" {
+		return
+	}
+
+	buf, err = generateMock(parseResults)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate REST API mock implementation. %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := writeFile(*mock, buf); err != nil {
+		log.Fatalf("Failed to write generated mock source to file %s. Reason: %s", *mock, err)
+	}
+
+	fmt.Println("Generated mock source written to file " + *mock)
 }
 
 // parseAST walks the AST represented by the interface we wish to generate an implementation for.
-// Returns ParseResult which contains request and response implementation details.
-func parseAST(file *ast.File, pkg string) *parse.ParseResult {
-	parser := parse.NewParser(file, pkg)
+// Returns a ParseResult per request builder which contains request and response implementation details.
+func parseAST(fset *token.FileSet, file *ast.File, pkg string) ([]*parse.ParseResult, error) {
+	parser := parse.NewParserWithFileSet(fset, file, pkg)
 	return parser.Parse()
 }
 
+// printParseError prints the message followed by every problem found in the REST API definition,
+// each on its own line.
+func printParseError(message string, err error) {
+	fmt.Fprintln(os.Stderr, message)
+	scanner.PrintError(os.Stderr, err)
+}
+
 // generateBuilder transforms the parsed information in to a request builder and response golang file.
-func generateBuilder(r *parse.ParseResult) ([]byte, error) {
+func generateBuilder(r []*parse.ParseResult) ([]byte, error) {
 	return generate.Generate(r)
 }
 
+// generateMock transforms the parsed information in to a mock request builder golang file.
+func generateMock(r []*parse.ParseResult) ([]byte, error) {
+	return generate.GenerateMock(r)
+}
+
 // writeFile persists the data to the specified file
 func writeFile(filename string, data []byte) error {
 	return ioutil.WriteFile(filename, data, 0644)
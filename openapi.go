@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	"github.com/jsaund/gorest/openapi"
+	"github.com/jsaund/gorest/parse"
+)
+
+// exportOpenAPI implements the openapi command which describes the REST API definitions
+// contained in the files as an OpenAPI 3 document.
+// Usage: gorest openapi [flags] [files...]
+func exportOpenAPI(args []string) {
+	flags := flag.NewFlagSet("openapi", flag.ExitOnError)
+	output := flags.String("output", "", "name of output file containing the OpenAPI document (if absent then Stdout is used)")
+	formatName := flags.String("format", "", "format of the OpenAPI document, yaml or json (defaults to the output file extension or yaml)")
+	title := flags.String("title", "REST API", "title of the API")
+	version := flags.String("version", "1.0.0", "version of the API")
+	server := flags.String("server", "", "base URL of the API (optional)")
+	dir := flags.String("dir", "", "directory of the package containing REST API to describe (all files of the package are parsed with full type information)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: gorest openapi [flags] [files...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *dir == "" && flags.NArg() == 0 {
+		flags.Usage()
+		fmt.Fprintln(os.Stderr, "Expects at least one input file or package directory")
+		os.Exit(1)
+	}
+
+	var results []*parse.ParseResult
+	if *dir != "" {
+		r, err := parse.ParsePackage(*dir)
+		if err != nil {
+			printParseError(fmt.Sprintf("Failed to parse REST API definition in package %s:", *dir), err)
+			os.Exit(1)
+		}
+		results = append(results, r...)
+	}
+	for _, filename := range flags.Args() {
+		fileset := token.NewFileSet()
+		f, err := parser.ParseFile(fileset, filename, nil, parser.ParseComments)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse input filename. Is input filename %s valid?\n", filename)
+			os.Exit(1)
+		}
+		r, err := parseAST(fileset, f, f.Name.Name)
+		if err != nil {
+			printParseError(fmt.Sprintf("Failed to parse REST API definition in %s:", filename), err)
+			os.Exit(1)
+		}
+		results = append(results, r...)
+	}
+
+	var servers []string
+	if *server != "" {
+		servers = append(servers, *server)
+	}
+	doc, err := openapi.Export(results, openapi.Info{Title: *title, Version: *version}, servers...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to describe REST API. %s\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range doc.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	if *formatName == "" {
+		*formatName = "yaml"
+		if filepath.Ext(*output) == ".json" {
+			*formatName = "json"
+		}
+	}
+
+	var buf []byte
+	switch *formatName {
+	case "json":
+		buf, err = doc.JSON()
+	case "yaml":
+		buf, err = doc.YAML()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %s. Expects yaml or json\n", *formatName)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode OpenAPI document. %s\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(buf)
+		return
+	}
+	if err := writeFile(*output, buf); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write OpenAPI document to file %s. Reason: %s\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Println("OpenAPI document written to file " + *output)
+}
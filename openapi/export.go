@@ -0,0 +1,429 @@
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jsaund/gorest/parse"
+)
+
+const (
+	contentTypeJSON      = "application/json"
+	contentTypeForm      = "application/x-www-form-urlencoded"
+	contentTypeMultipart = "multipart/form-data"
+	componentsPrefix     = "#/components/schemas/"
+)
+
+// converterContentTypes are the media types of the converters registered by restclient
+var converterContentTypes = map[string]string{
+	"":     contentTypeJSON,
+	"json": contentTypeJSON,
+	"xml":  "application/xml",
+	"form": contentTypeForm,
+}
+
+// exporter builds a document and the component schemas it refers to.
+type exporter struct {
+	doc *Document
+}
+
+// Export describes the request builders as an OpenAPI 3 document.
+// Parameter, body and response schemas are derived from the Go types. Struct types
+// are only described in detail when the request builders were parsed with type
+// information, otherwise named types are described as objects. Requests sent with an
+// HTTP method which OpenAPI can not describe, such as PROPFIND, are skipped and listed
+// in the warnings of the document.
+func Export(results []*parse.ParseResult, info Info, servers ...string) (*Document, error) {
+	e := &exporter{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+		},
+	}
+	for _, server := range servers {
+		e.doc.Servers = append(e.doc.Servers, &Server{URL: server})
+	}
+
+	for _, r := range results {
+		item, ok := e.doc.Paths[r.ApiEndpoint]
+		if !ok {
+			item = &PathItem{}
+		}
+		op := item.operation(r.HttpMethod)
+		if op == nil {
+			// Path items only describe the standard HTTP methods
+			e.doc.Warnings = append(e.doc.Warnings, fmt.Sprintf("%s: HTTP method %s can not be described by OpenAPI and is skipped", r.RequestType, r.HttpMethod))
+			continue
+		}
+		e.doc.Paths[r.ApiEndpoint] = item
+		if *op != nil {
+			return nil, fmt.Errorf("%s: operation %s %s is declared more than once", r.RequestType, r.HttpMethod, r.ApiEndpoint)
+		}
+		*op = e.operation(r)
+	}
+	return e.doc, nil
+}
+
+// operation describes a single request builder.
+func (e *exporter) operation(r *parse.ParseResult) *Operation {
+	op := &Operation{
+		OperationID: operationID(r.RequestType),
+		Responses:   make(map[string]*Response),
+	}
+	if r.ServiceType != "" {
+		// The requests of a service are named after their method
+		op.OperationID = r.SyncResponse.Names[0].Name
+	}
+
+	params := []struct {
+		in     string
+		fields []*ast.Field
+	}{
+		{"path", r.PathSubstitutions},
+		{"query", r.QueryParams},
+		{"header", r.HeaderParams},
+	}
+	for _, p := range params {
+		for _, f := range p.fields {
+			param := &Parameter{
+				Name:     annotationValue(f),
+				In:       p.in,
+				Required: p.in == "path",
+				Schema:   e.paramSchema(r, f),
+			}
+			if p.in == "query" && annotationArg(f, "format") == "csv" {
+				// Values are sent as a single comma separated value instead of repeated keys
+				explode := false
+				param.Explode = &explode
+			}
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+	op.Parameters = append(op.Parameters, staticHeaders(r)...)
+
+	switch {
+	case len(r.PostParams) > 0:
+		for _, f := range r.PostParams {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					bodyContentType(r): {Schema: e.paramSchema(r, f)},
+				},
+			}
+		}
+	case len(r.PostMultiPartParams) > 0:
+		// The form fields are sent as fields of the multipart body
+		fields := append(append([]*ast.Field{}, r.PostFormParams...), r.PostMultiPartParams...)
+		op.RequestBody = e.objectBody(r, contentTypeMultipart, fields)
+	case len(r.PostFormParams) > 0:
+		op.RequestBody = e.objectBody(r, contentTypeForm, r.PostFormParams)
+	}
+
+	codes := r.SuccessCodes
+	if len(codes) == 0 {
+		codes = []int{200}
+	}
+	for _, code := range codes {
+		response := &Response{Description: "Successful response"}
+		if r.ResponseType != "" && code != 204 {
+			response.Content = map[string]*MediaType{
+				bodyContentType(r): {Schema: e.responseSchema(r)},
+			}
+		}
+		op.Responses[strconv.Itoa(code)] = response
+	}
+	return op
+}
+
+// staticHeaders describes the headers declared by @HEADERS which are sent with every
+// request. Their values are the enum of the header, or its default when it has a single
+// value. A header also supplied by a header method is described by the method.
+func staticHeaders(r *parse.ParseResult) []*Parameter {
+	declared := make(map[string]bool)
+	for _, f := range r.HeaderParams {
+		declared[http.CanonicalHeaderKey(annotationValue(f))] = true
+	}
+	var params []*Parameter
+	for _, key := range sortedKeys(r.Headers) {
+		if declared[key] {
+			continue
+		}
+		schema := &Schema{Type: "string"}
+		for _, value := range r.Headers[key] {
+			schema.Enum = append(schema.Enum, value)
+		}
+		if len(schema.Enum) == 1 {
+			schema.Default = schema.Enum[0]
+		}
+		params = append(params, &Parameter{Name: key, In: "header", Schema: schema})
+	}
+	return params
+}
+
+// bodyContentType returns the media type of the request's @BODY and response, which
+// depends on its converter. The media type of a converter registered by the
+// application can not be known so it is described as binary data.
+func bodyContentType(r *parse.ParseResult) string {
+	if contentType, ok := converterContentTypes[r.ConverterName]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// objectBody describes a request body whose fields are supplied by the params.
+func (e *exporter) objectBody(r *parse.ParseResult, contentType string, fields []*ast.Field) *RequestBody {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for _, f := range fields {
+		schema.Properties[annotationValue(f)] = e.paramSchema(r, f)
+	}
+	return &RequestBody{
+		Content: map[string]*MediaType{
+			contentType: {Schema: schema},
+		},
+	}
+}
+
+// paramSchema describes the type of the parameter of the builder function. A function
+// accepting several values is described as an array of their type, whose items are any
+// value when the types of the values differ.
+func (e *exporter) paramSchema(r *parse.ParseResult, f *ast.Field) *Schema {
+	fn, ok := f.Type.(*ast.FuncType)
+	if !ok || len(fn.Params.List) == 0 {
+		return &Schema{}
+	}
+	if fn.Params.NumFields() > 1 {
+		for _, param := range fn.Params.List[1:] {
+			if types.ExprString(param.Type) != types.ExprString(fn.Params.List[0].Type) {
+				return &Schema{Type: "array", Items: &Schema{}}
+			}
+		}
+		return &Schema{Type: "array", Items: e.exprTypeSchema(r, fn.Params.List[0].Type)}
+	}
+	return e.exprTypeSchema(r, fn.Params.List[0].Type)
+}
+
+// exprTypeSchema describes the type expression using the type information of the
+// request when it is available.
+func (e *exporter) exprTypeSchema(r *parse.ParseResult, expr ast.Expr) *Schema {
+	if r.TypesInfo != nil {
+		if t := r.TypesInfo.TypeOf(expr); t != nil {
+			return e.typeSchema(t)
+		}
+	}
+	return e.exprSchema(expr)
+}
+
+// responseSchema describes the response type of the request, which is the result of the
+// method executing it. A pointer response is described by the type it points to and a
+// slice or map response by the schema of its elements.
+func (e *exporter) responseSchema(r *parse.ParseResult) *Schema {
+	if r.SyncResponse != nil {
+		if fn, ok := r.SyncResponse.Type.(*ast.FuncType); ok && fn.Results != nil && len(fn.Results.List) > 0 {
+			return e.exprTypeSchema(r, fn.Results.List[0].Type)
+		}
+	}
+	expr, err := parser.ParseExpr(r.ResponseType)
+	if err != nil {
+		return &Schema{}
+	}
+	return e.exprSchema(expr)
+}
+
+// component returns a reference to the component schema with the name. The schema is
+// declared by the describe func the first time the component is referred to, and a
+// component referred to while it is being described is not described again.
+// A nil describe func declares an object.
+func (e *exporter) component(name string, describe func() *Schema) *Schema {
+	if e.doc.Components == nil {
+		e.doc.Components = &Components{Schemas: make(map[string]*Schema)}
+	}
+	if _, ok := e.doc.Components.Schemas[name]; !ok {
+		// Declare the component before describing it so recursive types terminate
+		e.doc.Components.Schemas[name] = &Schema{Type: "object"}
+		if describe != nil {
+			e.doc.Components.Schemas[name] = describe()
+		}
+	}
+	return &Schema{Ref: componentsPrefix + name}
+}
+
+// exprSchema describes a type expression without type information.
+func (e *exporter) exprSchema(expr ast.Expr) *Schema {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if schema, ok := basicSchema(t.Name); ok {
+			return schema
+		}
+		return e.component(t.Name, nil)
+	case *ast.StarExpr:
+		return e.exprSchema(t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: e.exprSchema(t.Elt)}
+	case *ast.Ellipsis:
+		return &Schema{Type: "array", Items: e.exprSchema(t.Elt)}
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: e.exprSchema(t.Value)}
+	case *ast.SelectorExpr:
+		if schema, ok := qualifiedSchema(types.ExprString(t)); ok {
+			return schema
+		}
+		return &Schema{Type: "object"}
+	}
+	return &Schema{}
+}
+
+// typeSchema describes a type using its type information.
+func (e *exporter) typeSchema(t types.Type) *Schema {
+	switch u := t.(type) {
+	case *types.Named:
+		obj := u.Obj()
+		if obj.Pkg() != nil {
+			if schema, ok := qualifiedSchema(obj.Pkg().Name() + "." + obj.Name()); ok {
+				return schema
+			}
+		}
+		switch underlying := u.Underlying().(type) {
+		case *types.Basic:
+			return e.typeSchema(underlying)
+		case *types.Interface:
+			return e.component(obj.Name(), nil)
+		}
+		// Other named types are components, which may refer to themselves such as
+		// type Tree map[string]Tree
+		return e.component(obj.Name(), func() *Schema {
+			return e.typeSchema(u.Underlying())
+		})
+	case *types.Basic:
+		if schema, ok := basicSchema(u.Name()); ok {
+			return schema
+		}
+	case *types.Pointer:
+		return e.typeSchema(u.Elem())
+	case *types.Slice:
+		if basic, ok := u.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: e.typeSchema(u.Elem())}
+	case *types.Array:
+		return &Schema{Type: "array", Items: e.typeSchema(u.Elem())}
+	case *types.Map:
+		return &Schema{Type: "object", AdditionalProperties: e.typeSchema(u.Elem())}
+	case *types.Struct:
+		return e.structSchema(u)
+	}
+	return &Schema{}
+}
+
+// structSchema describes the JSON encoding of a struct.
+func (e *exporter) structSchema(s *types.Struct) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		name, skip := jsonName(field.Name(), s.Tag(i))
+		if skip || !field.Exported() {
+			continue
+		}
+		if field.Embedded() && name == field.Name() {
+			// Fields of an embedded struct are promoted in to the struct
+			if embedded, ok := field.Type().Underlying().(*types.Struct); ok {
+				for key, value := range e.structSchema(embedded).Properties {
+					schema.Properties[key] = value
+				}
+				continue
+			}
+		}
+		schema.Properties[name] = e.typeSchema(field.Type())
+	}
+	return schema
+}
+
+// jsonName returns the name of the field in its JSON encoding and whether it is omitted.
+func jsonName(field string, tag string) (string, bool) {
+	value := reflect.StructTag(tag).Get("json")
+	if value == "-" {
+		return "", true
+	}
+	if name := strings.Split(value, ",")[0]; name != "" {
+		return name, false
+	}
+	return field, false
+}
+
+// basicSchema describes the predeclared type with the name.
+func basicSchema(name string) (*Schema, bool) {
+	switch name {
+	case "string":
+		return &Schema{Type: "string"}, true
+	case "bool":
+		return &Schema{Type: "boolean"}, true
+	case "int", "int8", "int16", "uint", "uint8", "uint16", "byte":
+		return &Schema{Type: "integer"}, true
+	case "int32", "uint32", "rune":
+		return &Schema{Type: "integer", Format: "int32"}, true
+	case "int64", "uint64":
+		return &Schema{Type: "integer", Format: "int64"}, true
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}, true
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}, true
+	case "interface{}", "any":
+		return &Schema{}, true
+	}
+	return nil, false
+}
+
+// qualifiedSchema describes the well known type of another package.
+func qualifiedSchema(name string) (*Schema, bool) {
+	switch name {
+	case "time.Time":
+		return &Schema{Type: "string", Format: "date-time"}, true
+	case "time.Duration":
+		return &Schema{Type: "string"}, true
+	case "io.Reader", "io.ReadCloser", "os.File":
+		return &Schema{Type: "string", Format: "binary"}, true
+	case "json.RawMessage":
+		return &Schema{}, true
+	}
+	return nil, false
+}
+
+// operationID derives the operation id from the name of the request builder.
+// Example: GetPhotoDetailsRequestBuilder is GetPhotoDetails
+func operationID(requestType string) string {
+	for _, suffix := range []string{"RequestBuilder", "Request", "Builder"} {
+		if strings.HasSuffix(requestType, suffix) && len(requestType) > len(suffix) {
+			return strings.TrimSuffix(requestType, suffix)
+		}
+	}
+	return requestType
+}
+
+// annotationValue returns the value of the annotation of the builder function.
+func annotationValue(f *ast.Field) string {
+	if annotation, valid := parse.ExtractRequestAnnotation(f.Doc.Text()); valid {
+		return annotation.Value
+	}
+	return f.Names[0].Name
+}
+
+// annotationArg returns the named argument of the annotation of the builder function.
+func annotationArg(f *ast.Field, name string) string {
+	annotation, _ := parse.ExtractRequestAnnotation(f.Doc.Text())
+	return annotation.Args[name]
+}
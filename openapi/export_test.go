@@ -0,0 +1,481 @@
+package openapi
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/jsaund/gorest/parse"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const photoAPI = `
+	package test
+
+	import (
+		"io"
+		"time"
+	)
+
+	type Metadata struct {
+		Name    string    ` + "`json:\"name\"`" + `
+		Tags    []string  ` + "`json:\"tags,omitempty\"`" + `
+		Created time.Time
+		Parent  *Metadata ` + "`json:\"parent\"`" + `
+		secret  string
+		Ignored string    ` + "`json:\"-\"`" + `
+	}
+
+	type PhotoResponse struct {
+		ID int64 ` + "`json:\"id\"`" + `
+	}
+
+	func NewPhotoResponse(r io.Reader) (PhotoResponse, error) {
+		return PhotoResponse{}, nil
+	}
+
+	// @GET("/photos/{id}")
+	// @SUCCESS("200, 204")
+	type GetPhotoRequestBuilder interface {
+		// @PATH("id")
+		PhotoID(id string) GetPhotoRequestBuilder
+
+		// @QUERY("size")
+		Size(size int) GetPhotoRequestBuilder
+
+		// @HEADER("X-Trace")
+		Trace(trace string) GetPhotoRequestBuilder
+
+		// @SYNC("PhotoResponse")
+		Run() (PhotoResponse, error)
+	}
+
+	// @POST("/photos")
+	type PostPhotoRequestBuilder interface {
+		// @BODY("photo")
+		PhotoMetadata(metadata Metadata) PostPhotoRequestBuilder
+
+		// @SYNC("PhotoResponse")
+		Run() (PhotoResponse, error)
+	}
+
+	// @POST_FORM("/photos/{id}/comments")
+	type PostCommentRequest interface {
+		// @PATH("id")
+		PhotoID(id string) PostCommentRequest
+
+		// @FIELD("body")
+		Body(body string) PostCommentRequest
+
+		// @FIELD("rating")
+		Rating(rating float64) PostCommentRequest
+
+		// @SYNC("PhotoResponse")
+		Run() (PhotoResponse, error)
+	}
+`
+
+func parseFile(t *testing.T, src string) []*parse.ParseResult {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "input.go", src, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results, err := parse.NewParser(f, "test").Parse()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return results
+}
+
+func parsePackage(t *testing.T, src string) []*parse.ParseResult {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "input.go", src, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("test", fset, []*ast.File{f}, info)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	results, err := parse.NewPackageParser(fset, f, pkg, info).Parse()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return results
+}
+
+func TestExport(t *testing.T) {
+	doc, err := Export(parseFile(t, photoAPI), Info{Title: "Photos", Version: "2.0.0"}, "https://api.example.com")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, Info{Title: "Photos", Version: "2.0.0"}, doc.Info)
+	assert.Equal(t, []*Server{{URL: "https://api.example.com"}}, doc.Servers)
+	assert.Len(t, doc.Paths, 3)
+
+	get := doc.Paths["/photos/{id}"].Get
+	if assert.NotNil(t, get) {
+		assert.Equal(t, "GetPhoto", get.OperationID)
+		assert.Equal(t, []*Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+			{Name: "size", In: "query", Schema: &Schema{Type: "integer"}},
+			{Name: "X-Trace", In: "header", Schema: &Schema{Type: "string"}},
+		}, get.Parameters)
+		assert.Nil(t, get.RequestBody)
+		assert.Equal(t, map[string]*Response{
+			"200": {
+				Description: "Successful response",
+				Content: map[string]*MediaType{
+					"application/json": {Schema: &Schema{Ref: "#/components/schemas/PhotoResponse"}},
+				},
+			},
+			"204": {Description: "Successful response"},
+		}, get.Responses)
+	}
+
+	post := doc.Paths["/photos"].Post
+	if assert.NotNil(t, post) {
+		assert.Equal(t, "PostPhoto", post.OperationID)
+		assert.Equal(t, &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json": {Schema: &Schema{Ref: "#/components/schemas/Metadata"}},
+			},
+		}, post.RequestBody)
+	}
+
+	comment := doc.Paths["/photos/{id}/comments"].Post
+	if assert.NotNil(t, comment) {
+		assert.Equal(t, "PostComment", comment.OperationID)
+		assert.Equal(t, &RequestBody{
+			Content: map[string]*MediaType{
+				"application/x-www-form-urlencoded": {Schema: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"body":   {Type: "string"},
+						"rating": {Type: "number", Format: "double"},
+					},
+				}},
+			},
+		}, comment.RequestBody)
+	}
+
+	// Without type information named types can only be described as objects
+	assert.Equal(t, &Schema{Type: "object"}, doc.Components.Schemas["Metadata"])
+	assert.Equal(t, &Schema{Type: "object"}, doc.Components.Schemas["PhotoResponse"])
+}
+
+func TestExportPackage(t *testing.T) {
+	doc, err := Export(parsePackage(t, photoAPI), Info{Title: "Photos", Version: "1.0.0"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":    {Type: "string"},
+			"tags":    {Type: "array", Items: &Schema{Type: "string"}},
+			"Created": {Type: "string", Format: "date-time"},
+			"parent":  {Ref: "#/components/schemas/Metadata"},
+		},
+	}, doc.Components.Schemas["Metadata"])
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id": {Type: "integer", Format: "int64"},
+		},
+	}, doc.Components.Schemas["PhotoResponse"])
+}
+
+func TestExportCollectionResponses(t *testing.T) {
+	request := `
+		package test
+
+		type Photo struct {
+			ID int64 ` + "`json:\"id\"`" + `
+		}
+
+		// @GET("/photos")
+		type ListPhotosRequest interface {
+			// @SYNC("[]Photo")
+			Run() ([]Photo, error)
+		}
+
+		// @GET("/albums")
+		type ListAlbumsRequest interface {
+			// @SYNC("map[string]*Photo")
+			Run() (map[string]*Photo, error)
+		}
+	`
+	photoRef := &Schema{Ref: "#/components/schemas/Photo"}
+	for _, results := range [][]*parse.ParseResult{parseFile(t, request), parsePackage(t, request)} {
+		doc, err := Export(results, Info{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Collections are described by the schema of their elements
+		assert.Equal(t, &Schema{Type: "array", Items: photoRef},
+			doc.Paths["/photos"].Get.Responses["200"].Content["application/json"].Schema)
+		assert.Equal(t, &Schema{Type: "object", AdditionalProperties: photoRef},
+			doc.Paths["/albums"].Get.Responses["200"].Content["application/json"].Schema)
+		assert.Len(t, doc.Components.Schemas, 1)
+		assert.Contains(t, doc.Components.Schemas, "Photo")
+	}
+}
+
+func TestExportQueryFormat(t *testing.T) {
+	request := `
+		package test
+		// @GET("/photos")
+		type ListPhotosRequest interface {
+			// @QUERY("tags", format="csv")
+			Tags(tags []string) ListPhotosRequest
+
+			// @QUERY("ids")
+			IDs(ids []int) ListPhotosRequest
+
+			// @QUERY("size")
+			Size(width, height int) ListPhotosRequest
+
+			// @QUERY("filter")
+			Filter(name string, values ...int) ListPhotosRequest
+		}
+	`
+	doc, err := Export(parseFile(t, request), Info{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	explode := false
+	assert.Equal(t, []*Parameter{
+		{Name: "tags", In: "query", Explode: &explode, Schema: &Schema{Type: "array", Items: &Schema{Type: "string"}}},
+		{Name: "ids", In: "query", Schema: &Schema{Type: "array", Items: &Schema{Type: "integer"}}},
+		{Name: "size", In: "query", Schema: &Schema{Type: "array", Items: &Schema{Type: "integer"}}},
+		{Name: "filter", In: "query", Schema: &Schema{Type: "array", Items: &Schema{}}},
+	}, doc.Paths["/photos"].Get.Parameters)
+}
+
+func TestExportMultipartFields(t *testing.T) {
+	request := `
+		package test
+		// @POST("/photos")
+		type UploadPhotoRequest interface {
+			// @FIELD("caption")
+			Caption(caption string) UploadPhotoRequest
+
+			// @PART("photo")
+			Photo(photo []byte) UploadPhotoRequest
+		}
+	`
+	doc, err := Export(parseFile(t, request), Info{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The form fields are sent as fields of the multipart body
+	assert.Equal(t, &RequestBody{
+		Content: map[string]*MediaType{
+			"multipart/form-data": {Schema: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"caption": {Type: "string"},
+					"photo":   {Type: "string", Format: "byte"},
+				},
+			}},
+		},
+	}, doc.Paths["/photos"].Post.RequestBody)
+}
+
+func TestExportConverter(t *testing.T) {
+	request := `
+		package test
+		// @POST("/invoices")
+		// @CONVERTER("xml")
+		type CreateInvoiceRequest interface {
+			// @BODY("invoice")
+			Invoice(invoice Invoice) CreateInvoiceRequest
+
+			// @SYNC("InvoiceResponse")
+			Run() (InvoiceResponse, error)
+		}
+	`
+	doc, err := Export(parseFile(t, request), Info{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	op := doc.Paths["/invoices"].Post
+	assert.Contains(t, op.RequestBody.Content, "application/xml")
+	assert.Contains(t, op.Responses["200"].Content, "application/xml")
+}
+
+func TestExportService(t *testing.T) {
+	service := `
+		package test
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(/* @PATH("id") */ id string) (PhotoResponse, error)
+		}
+	`
+	doc, err := Export(parseFile(t, service), Info{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The operation is named after the method of the service
+	op := doc.Paths["/photos/{id}"].Get
+	assert.Equal(t, "GetPhoto", op.OperationID)
+	if assert.Len(t, op.Parameters, 1) {
+		assert.Equal(t, "id", op.Parameters[0].Name)
+		assert.Equal(t, "path", op.Parameters[0].In)
+	}
+}
+
+func TestExportDuplicateOperation(t *testing.T) {
+	request := `
+		package test
+		// @GET("/photos")
+		type ListPhotosRequest interface {
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+		// @GET("/photos")
+		type SearchPhotosRequest interface {
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+		}
+	`
+	_, err := Export(parseFile(t, request), Info{})
+	assert.Error(t, err)
+}
+
+func TestExportStaticHeaders(t *testing.T) {
+	request := `
+		package test
+		// @GET("/repos")
+		// @HEADERS("Accept: application/vnd.github.v3+json", "X-Api-Version: 2", "X-Api-Version: 3", "x-trace: on")
+		type ListReposRequest interface {
+			// @HEADER("X-Trace")
+			Trace(trace string) ListReposRequest
+		}
+	`
+	doc, err := Export(parseFile(t, request), Info{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// A header declared by a header method is described by the method
+	assert.Equal(t, []*Parameter{
+		{Name: "X-Trace", In: "header", Schema: &Schema{Type: "string"}},
+		{Name: "Accept", In: "header", Schema: &Schema{Type: "string", Enum: []interface{}{"application/vnd.github.v3+json"}, Default: "application/vnd.github.v3+json"}},
+		{Name: "X-Api-Version", In: "header", Schema: &Schema{Type: "string", Enum: []interface{}{"2", "3"}}},
+	}, doc.Paths["/repos"].Get.Parameters)
+}
+
+func TestExportCustomMethod(t *testing.T) {
+	request := `
+		package test
+		// @HTTP(method="PROPFIND", path="/files/{id}")
+		type GetFilePropertiesRequest interface {
+			// @PATH("id")
+			FileID(id string) GetFilePropertiesRequest
+		}
+		// @GET("/files")
+		type ListFilesRequest interface {
+			// @SYNC("FilesResponse")
+			Run() (FilesResponse, error)
+		}
+	`
+	doc, err := Export(parseFile(t, request), Info{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The request is skipped instead of failing the export of the other requests
+	assert.NotContains(t, doc.Paths, "/files/{id}")
+	assert.NotNil(t, doc.Paths["/files"].Get)
+	assert.Equal(t, []string{"GetFilePropertiesRequest: HTTP method PROPFIND can not be described by OpenAPI and is skipped"}, doc.Warnings)
+}
+
+func TestExportRecursiveTypes(t *testing.T) {
+	request := `
+		package test
+
+		import "io"
+
+		type Tree map[string]Tree
+
+		func NewTree(r io.Reader) (Tree, error) {
+			return nil, nil
+		}
+
+		type Node struct {
+			Children []Node ` + "`json:\"children\"`" + `
+			Tree     Tree   ` + "`json:\"tree\"`" + `
+		}
+
+		// @POST("/nodes")
+		type CreateNodeRequest interface {
+			// @BODY("node")
+			Node(node Node) CreateNodeRequest
+
+			// @SYNC("Tree")
+			Run() (Tree, error)
+		}
+	`
+	doc, err := Export(parsePackage(t, request), Info{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Named types are described once as components which refer to themselves
+	assert.Equal(t, &Schema{
+		Type:                 "object",
+		AdditionalProperties: &Schema{Ref: "#/components/schemas/Tree"},
+	}, doc.Components.Schemas["Tree"])
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"children": {Type: "array", Items: &Schema{Ref: "#/components/schemas/Node"}},
+			"tree":     {Ref: "#/components/schemas/Tree"},
+		},
+	}, doc.Components.Schemas["Node"])
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/Tree"}, doc.Paths["/nodes"].Post.Responses["200"].Content["application/json"].Schema)
+}
+
+func TestDocumentEncoding(t *testing.T) {
+	doc, err := Export(parseFile(t, photoAPI), Info{Title: "Photos", Version: "1.0.0"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	buf, err := doc.JSON()
+	if assert.NoError(t, err) {
+		var decoded Document
+		assert.NoError(t, json.Unmarshal(buf, &decoded))
+		assert.Equal(t, doc, &decoded)
+	}
+
+	buf, err = doc.YAML()
+	if assert.NoError(t, err) {
+		var decoded Document
+		assert.NoError(t, yaml.Unmarshal(buf, &decoded))
+		assert.Equal(t, doc, &decoded)
+	}
+}
@@ -0,0 +1,679 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+const (
+	parametersPrefix    = "#/components/parameters/"
+	requestBodiesPrefix = "#/components/requestBodies/"
+	responsesPrefix     = "#/components/responses/"
+)
+
+// importMethods are the HTTP methods which can be declared with their own annotation
+// and whether the annotation sends a request body. Other requests are declared with
+// the generic @HTTP annotation.
+var importMethods = map[string]bool{
+	"GET":     false,
+	"PUT":     true,
+	"POST":    true,
+	"DELETE":  false,
+	"HEAD":    false,
+	"PATCH":   true,
+	"OPTIONS": false,
+}
+
+// initialisms are the words which are written in upper case in Go identifiers
+var initialisms = map[string]bool{
+	"API":  true,
+	"HTML": true,
+	"HTTP": true,
+	"ID":   true,
+	"IP":   true,
+	"JSON": true,
+	"URI":  true,
+	"URL":  true,
+	"UUID": true,
+	"XML":  true,
+}
+
+var importTmpl = template.Must(template.New("import").Parse(importTemplate))
+
+// importFile is the data rendered by the import template.
+type importFile struct {
+	Title       string
+	Version     string
+	PackageName string
+	Imports     []string
+	Requests    []*importRequest
+	Types       []*importType
+}
+
+// importRequest is an annotated request builder interface.
+type importRequest struct {
+	Name    string
+	Summary string
+	Method  string
+	Path    string
+	// Generic is set for requests declared with the @HTTP annotation
+	Generic      bool
+	HasBody      bool
+	SuccessCodes string
+	Params       []*importParam
+	ResponseType string
+}
+
+// importParam is an annotated builder function of a request builder interface.
+type importParam struct {
+	Annotation string
+	Value      string
+	// Format is the format of slice parameters, empty unless values are comma separated
+	Format string
+	Func   string
+	Name   string
+	Type   string
+}
+
+// importType is a type declaration. Struct types have fields, all other types are
+// declared with their underlying type.
+type importType struct {
+	Name       string
+	Underlying string
+	Fields     []*importField
+	// Constructor is set for response types which require a New<Response> constructor
+	Constructor bool
+	// Empty is set for response types which do not have a body to decode
+	Empty bool
+}
+
+type importField struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+// apiImporter converts the operations of a document in to request builder interfaces.
+type apiImporter struct {
+	doc  *Document
+	file *importFile
+	// components maps the name of a component schema to the name of its type
+	components map[string]string
+	names      map[string]bool
+	types      map[string]*importType
+	imports    map[string]bool
+}
+
+// Import generates Go source declaring an annotated request builder interface for every
+// operation of the document together with the types of the request and response bodies.
+// Response types which are not structs are declared with the New<Response> constructor
+// which decodes the response body so the source can be passed to the parse and generate
+// packages as is.
+func Import(doc *Document, pkg string) ([]byte, error) {
+	i := &apiImporter{
+		doc: doc,
+		file: &importFile{
+			Title:       doc.Info.Title,
+			Version:     doc.Info.Version,
+			PackageName: pkg,
+		},
+		components: make(map[string]string),
+		names:      make(map[string]bool),
+		types:      make(map[string]*importType),
+		imports:    make(map[string]bool),
+	}
+
+	if doc.Components != nil {
+		// Component schemas are named first so references resolve to their types. Names
+		// which look like a constructor are named last so they are the ones renamed.
+		names := sortedKeys(doc.Components.Schemas)
+		for _, constructors := range []bool{false, true} {
+			for _, name := range names {
+				if strings.HasPrefix(goName(name), "New") == constructors {
+					i.components[name] = i.unique(goName(name))
+				}
+			}
+		}
+		for _, name := range names {
+			if err := i.declare(i.components[name], doc.Components.Schemas[name]); err != nil {
+				return nil, fmt.Errorf("schema %s: %s", name, err)
+			}
+		}
+	}
+
+	for _, path := range sortedKeys(doc.Paths) {
+		item := doc.Paths[path]
+		for _, method := range operationMethods {
+			op := *item.operation(method)
+			if op == nil {
+				continue
+			}
+			if err := i.request(path, method, item, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %s", method, path, err)
+			}
+		}
+	}
+
+	for path := range i.imports {
+		i.file.Imports = append(i.file.Imports, path)
+	}
+	sort.Strings(i.file.Imports)
+
+	var buf bytes.Buffer
+	if err := importTmpl.Execute(&buf, i.file); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// request declares the request builder interface of the operation.
+func (i *apiImporter) request(path string, method string, item *PathItem, op *Operation) error {
+	name := goName(op.OperationID)
+	if op.OperationID == "" {
+		name = goName(strings.ToLower(method) + " " + path)
+	}
+	hasBody, ok := importMethods[method]
+	r := &importRequest{
+		Name:    i.unique(name + "RequestBuilder"),
+		Summary: summary(op.Summary),
+		Method:  method,
+		Path:    path,
+		Generic: !ok,
+	}
+	name = strings.TrimSuffix(r.Name, "RequestBuilder")
+
+	funcs := map[string]bool{"Run": true, "RunAsync": true}
+	addParam := func(annotation string, value string, hint string, schema *Schema) (*importParam, error) {
+		t, err := i.goType(name+goName(hint), schema)
+		if err != nil {
+			return nil, err
+		}
+		f := goName(hint)
+		for funcs[f] {
+			f += goName(strings.ToLower(annotation))
+		}
+		funcs[f] = true
+		param := &importParam{
+			Annotation: annotation,
+			Value:      value,
+			Func:       f,
+			Name:       paramName(hint),
+			Type:       t,
+		}
+		r.Params = append(r.Params, param)
+		return param, nil
+	}
+
+	params, err := i.parameters(item.Parameters, op.Parameters)
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		var annotation string
+		switch p.In {
+		case "path":
+			annotation = "PATH"
+		case "query":
+			annotation = "QUERY"
+		case "header":
+			annotation = "HEADER"
+		default:
+			return fmt.Errorf("parameter %s in %s is not supported", p.Name, p.In)
+		}
+		param, err := addParam(annotation, p.Name, p.Name, p.Schema)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %s", p.Name, err)
+		}
+		if p.In == "query" && p.Explode != nil && !*p.Explode && strings.HasPrefix(param.Type, "[]") {
+			param.Format = "csv"
+		}
+	}
+
+	if op.RequestBody != nil {
+		body, err := i.requestBody(op.RequestBody)
+		if err != nil {
+			return err
+		}
+		contentType, media := mediaType(body.Content)
+		if !hasBody {
+			r.Generic = true
+			r.HasBody = true
+		}
+		switch contentType {
+		case contentTypeJSON:
+			hint := "Body"
+			if media.Schema != nil && strings.HasPrefix(media.Schema.Ref, componentsPrefix) {
+				hint = strings.TrimPrefix(media.Schema.Ref, componentsPrefix)
+			}
+			if _, err := addParam("BODY", paramName(hint), hint, media.Schema); err != nil {
+				return fmt.Errorf("request body: %s", err)
+			}
+		case contentTypeForm, contentTypeMultipart:
+			annotation := "PART"
+			if contentType == contentTypeForm {
+				annotation = "FIELD"
+				if method == "POST" {
+					r.Method = "POST_FORM"
+				}
+			}
+			schema, err := i.resolve(media.Schema)
+			if err != nil {
+				return err
+			}
+			for _, key := range sortedKeys(schema.Properties) {
+				param, err := addParam(annotation, key, key, schema.Properties[key])
+				if err != nil {
+					return fmt.Errorf("request body field %s: %s", key, err)
+				}
+				if annotation == "PART" && param.Type == "[]byte" {
+					// File parts are streamed instead of being held in memory
+					param.Type = "io.Reader"
+					i.imports["io"] = true
+				}
+			}
+		default:
+			return fmt.Errorf("request body content type %s is not supported", contentType)
+		}
+	}
+
+	var codes []string
+	var response *Response
+	for _, code := range sortedKeys(op.Responses) {
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 200 || status > 299 {
+			continue
+		}
+		codes = append(codes, code)
+		if response == nil || len(response.Content) == 0 {
+			if response, err = i.response(op.Responses[code]); err != nil {
+				return fmt.Errorf("response %s: %s", code, err)
+			}
+		}
+	}
+	if len(codes) > 1 || (len(codes) == 1 && codes[0] != "200") {
+		r.SuccessCodes = strings.Join(codes, ", ")
+	}
+	if r.ResponseType, err = i.responseType(name+"Response", response); err != nil {
+		return err
+	}
+
+	i.file.Requests = append(i.file.Requests, r)
+	return nil
+}
+
+// parameters returns the parameters of the operation. Parameters of the operation
+// override the parameters of the path with the same name and location.
+func (i *apiImporter) parameters(pathParams []*Parameter, opParams []*Parameter) ([]*Parameter, error) {
+	var params []*Parameter
+	index := make(map[string]int)
+	for _, p := range append(append([]*Parameter{}, pathParams...), opParams...) {
+		if p.Ref != "" {
+			name := strings.TrimPrefix(p.Ref, parametersPrefix)
+			if i.doc.Components == nil || i.doc.Components.Parameters[name] == nil {
+				return nil, fmt.Errorf("parameter %s is not declared", p.Ref)
+			}
+			p = i.doc.Components.Parameters[name]
+		}
+		key := p.In + " " + p.Name
+		if n, ok := index[key]; ok {
+			params[n] = p
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// requestBody resolves a reference to a request body component.
+func (i *apiImporter) requestBody(body *RequestBody) (*RequestBody, error) {
+	if body.Ref == "" {
+		return body, nil
+	}
+	name := strings.TrimPrefix(body.Ref, requestBodiesPrefix)
+	if i.doc.Components == nil || i.doc.Components.RequestBodies[name] == nil {
+		return nil, fmt.Errorf("request body %s is not declared", body.Ref)
+	}
+	return i.doc.Components.RequestBodies[name], nil
+}
+
+// response resolves a reference to a response component.
+func (i *apiImporter) response(response *Response) (*Response, error) {
+	if response.Ref == "" {
+		return response, nil
+	}
+	name := strings.TrimPrefix(response.Ref, responsesPrefix)
+	if i.doc.Components == nil || i.doc.Components.Responses[name] == nil {
+		return nil, fmt.Errorf("response %s is not declared", response.Ref)
+	}
+	return i.doc.Components.Responses[name], nil
+}
+
+// responseType returns the name of the type the response is decoded in to. Responses
+// without a named type are declared with the name.
+func (i *apiImporter) responseType(name string, response *Response) (string, error) {
+	var schema *Schema
+	if response != nil {
+		if contentType, media := mediaType(response.Content); contentType == contentTypeJSON {
+			schema = media.Schema
+		}
+	}
+	if schema == nil {
+		t := &importType{Name: i.unique(name), Underlying: "struct{}", Constructor: true, Empty: true}
+		i.addType(t)
+		i.imports["io"] = true
+		return t.Name, nil
+	}
+
+	goType, err := i.goType(name, schema)
+	if err != nil {
+		return "", fmt.Errorf("response: %s", err)
+	}
+	t, ok := i.types[goType]
+	if !ok {
+		t = &importType{Name: i.unique(name), Underlying: goType}
+		i.addType(t)
+	}
+	if len(t.Fields) == 0 && !strings.HasPrefix(t.Underlying, "struct") {
+		// Struct responses are decoded by the generated implementation
+		t.Constructor = true
+		i.imports["io"] = true
+		i.imports["encoding/json"] = true
+	}
+	return t.Name, nil
+}
+
+// resolve returns the component schema the schema refers to.
+func (i *apiImporter) resolve(schema *Schema) (*Schema, error) {
+	if schema == nil {
+		return &Schema{}, nil
+	}
+	if schema.Ref == "" {
+		return schema, nil
+	}
+	name := strings.TrimPrefix(schema.Ref, componentsPrefix)
+	if i.doc.Components == nil || i.doc.Components.Schemas[name] == nil {
+		return nil, fmt.Errorf("schema %s is not declared", schema.Ref)
+	}
+	return i.resolve(i.doc.Components.Schemas[name])
+}
+
+// declare declares the component schema as a named type.
+func (i *apiImporter) declare(name string, schema *Schema) error {
+	t := &importType{Name: name}
+	i.addType(t)
+	if isStruct(schema) {
+		return i.structFields(t, schema)
+	}
+	underlying, err := i.goType(name, schema)
+	if err != nil {
+		return err
+	}
+	t.Underlying = underlying
+	return nil
+}
+
+// goType returns the Go type of the schema. Objects without a name are declared as
+// struct types named after the hint.
+func (i *apiImporter) goType(hint string, schema *Schema) (string, error) {
+	if schema == nil {
+		return "interface{}", nil
+	}
+	if schema.Ref != "" {
+		if !strings.HasPrefix(schema.Ref, componentsPrefix) {
+			return "", fmt.Errorf("schema %s is not supported", schema.Ref)
+		}
+		name, ok := i.components[strings.TrimPrefix(schema.Ref, componentsPrefix)]
+		if !ok {
+			return "", fmt.Errorf("schema %s is not declared", schema.Ref)
+		}
+		return name, nil
+	}
+	if len(schema.OneOf) > 0 || len(schema.AnyOf) > 0 {
+		i.imports["encoding/json"] = true
+		return "json.RawMessage", nil
+	}
+	if len(schema.AllOf) == 1 && len(schema.Properties) == 0 {
+		return i.goType(hint, schema.AllOf[0])
+	}
+	if isStruct(schema) {
+		t := &importType{Name: i.unique(hint)}
+		i.addType(t)
+		return t.Name, i.structFields(t, schema)
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			i.imports["time"] = true
+			return "time.Time", nil
+		case "byte", "binary":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		switch schema.Format {
+		case "int32":
+			return "int32", nil
+		case "int64":
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		if schema.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		item, err := i.goType(hint+"Item", schema.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "object":
+		if schema.AdditionalProperties != nil {
+			value, err := i.goType(hint+"Value", schema.AdditionalProperties)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + value, nil
+		}
+		return "map[string]interface{}", nil
+	}
+	return "interface{}", nil
+}
+
+// structFields declares the fields of the struct type from the properties of the
+// schema. The properties of the schemas it is composed of are embedded.
+func (i *apiImporter) structFields(t *importType, schema *Schema) error {
+	for _, s := range schema.AllOf {
+		if s.Ref != "" {
+			embedded, err := i.goType(t.Name, s)
+			if err != nil {
+				return err
+			}
+			t.Fields = append(t.Fields, &importField{Type: embedded})
+			continue
+		}
+		if err := i.structFields(t, s); err != nil {
+			return err
+		}
+	}
+
+	required := make(map[string]bool)
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	fields := make(map[string]bool)
+	for _, f := range t.Fields {
+		fields[f.Name] = true
+	}
+	for _, key := range sortedKeys(schema.Properties) {
+		property := schema.Properties[key]
+		goType, err := i.goType(t.Name+goName(key), property)
+		if err != nil {
+			return fmt.Errorf("property %s: %s", key, err)
+		}
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+			// Optional objects are pointers so they are omitted when they are absent
+			if resolved, err := i.resolve(property); err == nil && isStruct(resolved) {
+				goType = "*" + goType
+			}
+		}
+		name := goName(key)
+		for fields[name] {
+			name += "_"
+		}
+		fields[name] = true
+		t.Fields = append(t.Fields, &importField{
+			Name: name,
+			Type: goType,
+			Tag:  "`json:\"" + tag + "\"`",
+		})
+	}
+	return nil
+}
+
+// addType declares the type. The name must have been returned by unique.
+func (i *apiImporter) addType(t *importType) {
+	i.types[t.Name] = t
+	i.file.Types = append(i.file.Types, t)
+}
+
+// unique returns the name or the name with a numeric suffix if it is already declared.
+// A name is also taken if it is the New<Response> constructor of a declared name.
+func (i *apiImporter) unique(name string) string {
+	candidate := name
+	for n := 2; i.names[candidate] || i.names["New"+candidate] ||
+		(strings.HasPrefix(candidate, "New") && i.names[strings.TrimPrefix(candidate, "New")]); n++ {
+		candidate = name + strconv.Itoa(n)
+	}
+	i.names[candidate] = true
+	return candidate
+}
+
+// isStruct returns true if the schema describes an object with a fixed set of properties.
+func isStruct(schema *Schema) bool {
+	return schema != nil && schema.Ref == "" && (len(schema.Properties) > 0 || len(schema.AllOf) > 1 ||
+		(len(schema.AllOf) == 1 && schema.Type == "object"))
+}
+
+// mediaType returns the preferred content type of the content and its media type.
+// JSON is preferred followed by forms, any JSON based content type is treated as JSON.
+func mediaType(content map[string]*MediaType) (string, *MediaType) {
+	for _, contentType := range []string{contentTypeJSON, contentTypeForm, contentTypeMultipart} {
+		if media, ok := content[contentType]; ok {
+			return contentType, media
+		}
+	}
+	for _, contentType := range sortedKeys(content) {
+		if strings.HasSuffix(contentType, "+json") {
+			return contentTypeJSON, content[contentType]
+		}
+	}
+	for _, contentType := range sortedKeys(content) {
+		return contentType, content[contentType]
+	}
+	return "", nil
+}
+
+// summary returns the first line of the summary of an operation in a form which
+// follows the name of the request builder in its doc comment.
+func summary(s string) string {
+	s = strings.SplitN(strings.TrimSpace(s), "\n", 2)[0]
+	r := []rune(s)
+	if len(r) > 1 && unicode.IsUpper(r[0]) && !unicode.IsUpper(r[1]) {
+		r[0] = unicode.ToLower(r[0])
+	}
+	return string(r)
+}
+
+// goName converts the name to an exported Go identifier.
+// Example: photo_id is PhotoID
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		upper := strings.ToUpper(word)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		if strings.HasSuffix(upper, "S") && initialisms[strings.TrimSuffix(upper, "S")] {
+			// Plural initialisms such as IDs
+			b.WriteString(strings.TrimSuffix(upper, "S") + "s")
+			continue
+		}
+		r := []rune(word)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	s := b.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// paramName converts the name to an unexported Go identifier which may be used as
+// the name of a parameter of a builder function.
+// Example: photo_id is photoID
+func paramName(name string) string {
+	parts := words(name)
+	if len(parts) == 0 {
+		return "value"
+	}
+	s := strings.ToLower(parts[0])
+	if len(parts) > 1 {
+		s += goName(strings.Join(parts[1:], " "))
+	}
+	if !unicode.IsLetter([]rune(s)[0]) {
+		s = "x" + s
+	}
+	if token.IsKeyword(s) {
+		s += "Value"
+	}
+	return s
+}
+
+// words splits the name in to words at every character which is not a letter or a
+// digit and at every change from lower to upper case.
+func words(name string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		start := 0
+		runes := []rune(field)
+		for j := 1; j < len(runes); j++ {
+			if unicode.IsUpper(runes[j]) && !unicode.IsUpper(runes[j-1]) {
+				words = append(words, string(runes[start:j]))
+				start = j
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// sortedKeys returns the keys of the map, which must be keyed by strings, in ascending order.
+func sortedKeys(m interface{}) []string {
+	values := reflect.ValueOf(m).MapKeys()
+	keys := make([]string, 0, len(values))
+	for _, key := range values {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
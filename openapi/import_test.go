@@ -0,0 +1,363 @@
+package openapi
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/jsaund/gorest/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+const photoDocument = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Photos", "version": "1.0.0"},
+  "paths": {
+    "/photos/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {
+        "operationId": "getPhoto",
+        "summary": "Returns the photo.",
+        "parameters": [{"name": "size", "in": "query", "schema": {"type": "integer"}}],
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Photo"}}}}}
+      },
+      "delete": {
+        "responses": {"204": {"description": "deleted"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Photo": {
+        "type": "object",
+        "required": ["id"],
+        "properties": {
+          "id": {"type": "string"},
+          "tags": {"type": "array", "items": {"type": "string"}},
+          "owner": {"type": "object", "properties": {"name": {"type": "string"}}}
+        }
+      }
+    }
+  }
+}
+`
+
+func TestDecode(t *testing.T) {
+	doc, err := Decode([]byte(photoDocument))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, Info{Title: "Photos", Version: "1.0.0"}, doc.Info)
+	if assert.NotNil(t, doc.Paths["/photos/{id}"]) {
+		item := doc.Paths["/photos/{id}"]
+		assert.Equal(t, "getPhoto", item.Get.OperationID)
+		assert.NotNil(t, item.Delete)
+		assert.Nil(t, item.Post)
+		assert.Equal(t, []*Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		}, item.Parameters)
+	}
+	assert.Equal(t, []string{"id"}, doc.Components.Schemas["Photo"].Required)
+
+	doc, err = Decode([]byte(`{"openapi": "3.0.3", "components": {"schemas": {"Labels": {"type": "object", "additionalProperties": true}}}}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{}}, doc.Components.Schemas["Labels"])
+	}
+}
+
+func TestJSONValue(t *testing.T) {
+	value := map[string]interface{}{
+		"responses": map[interface{}]interface{}{
+			200: map[string]interface{}{"description": "ok"},
+		},
+		"tags": []interface{}{map[interface{}]interface{}{"name": "photos"}},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{"description": "ok"},
+		},
+		"tags": []interface{}{map[string]interface{}{"name": "photos"}},
+	}, jsonValue(value))
+}
+
+func TestImport(t *testing.T) {
+	doc, err := Decode([]byte(photoDocument))
+	if !assert.NoError(t, err) {
+		return
+	}
+	src, err := Import(doc, "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expected := `// REST API imported from the OpenAPI document Photos 1.0.0 with gorest import-openapi.
+// Generate the request builder implementations with gorest.
+
+package test
+
+import (
+	"io"
+)
+
+// GetPhotoRequestBuilder returns the photo.
+// @GET("/photos/{id}")
+type GetPhotoRequestBuilder interface {
+	// @PATH("id")
+	ID(id string) GetPhotoRequestBuilder
+
+	// @QUERY("size")
+	Size(size int) GetPhotoRequestBuilder
+
+	// @SYNC("Photo")
+	Run() (Photo, error)
+}
+
+// @DELETE("/photos/{id}")
+// @SUCCESS("204")
+type DeletePhotosIDRequestBuilder interface {
+	// @PATH("id")
+	ID(id string) DeletePhotosIDRequestBuilder
+
+	// @SYNC("DeletePhotosIDResponse")
+	Run() (DeletePhotosIDResponse, error)
+}
+
+type Photo struct {
+	ID    string      ` + "`" + `json:"id"` + "`" + `
+	Owner *PhotoOwner ` + "`" + `json:"owner,omitempty"` + "`" + `
+	Tags  []string    ` + "`" + `json:"tags,omitempty"` + "`" + `
+}
+
+type PhotoOwner struct {
+	Name string ` + "`" + `json:"name,omitempty"` + "`" + `
+}
+
+type DeletePhotosIDResponse struct{}
+
+func NewDeletePhotosIDResponse(r io.Reader) (DeletePhotosIDResponse, error) {
+	return DeletePhotosIDResponse{}, nil
+}
+`
+	assert.Equal(t, expected, string(src))
+
+	// The source is a valid REST API definition
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "api.go", src, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		return
+	}
+	results, err := parse.NewParser(f, "test").Parse()
+	if assert.NoError(t, err) && assert.Len(t, results, 2) {
+		assert.Equal(t, "GetPhotoRequestBuilder", results[0].RequestType)
+		assert.Equal(t, "Photo", results[0].ResponseType)
+		assert.Equal(t, "DeletePhotosIDRequestBuilder", results[1].RequestType)
+		assert.Equal(t, []int{204}, results[1].SuccessCodes)
+	}
+}
+
+func TestImportRequestBody(t *testing.T) {
+	doc := &Document{
+		Paths: map[string]*PathItem{
+			"/photos": {
+				Post: &Operation{
+					OperationID: "createPhoto",
+					RequestBody: &RequestBody{Ref: requestBodiesPrefix + "NewPhoto"},
+					Responses: map[string]*Response{
+						"201": {Content: map[string]*MediaType{
+							"application/json": {Schema: &Schema{Ref: componentsPrefix + "Photo"}},
+						}},
+					},
+				},
+			},
+			"/photos/{id}/comments": {
+				Post: &Operation{
+					OperationID: "postComment",
+					Parameters: []*Parameter{
+						{Name: "id", In: "path", Schema: &Schema{Type: "integer", Format: "int64"}},
+					},
+					RequestBody: &RequestBody{Content: map[string]*MediaType{
+						"application/x-www-form-urlencoded": {Schema: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"body": {Type: "string"},
+								"type": {Type: "string"},
+							},
+						}},
+					}},
+					Responses: map[string]*Response{"200": {}},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: map[string]*Schema{
+				"NewPhoto": {Type: "object", Properties: map[string]*Schema{"title": {Type: "string"}}},
+				"Photo": {AllOf: []*Schema{
+					{Ref: componentsPrefix + "NewPhoto"},
+					{Type: "object", Properties: map[string]*Schema{
+						"created_at": {Type: "string", Format: "date-time"},
+					}},
+				}},
+			},
+			RequestBodies: map[string]*RequestBody{
+				"NewPhoto": {Content: map[string]*MediaType{
+					"application/json": {Schema: &Schema{Ref: componentsPrefix + "NewPhoto"}},
+				}},
+			},
+		},
+	}
+	src, err := Import(doc, "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The component NewPhoto is renamed as it is the constructor of Photo
+	assert.Contains(t, string(src), "// @POST(\"/photos\")\n// @SUCCESS(\"201\")\n")
+	assert.Contains(t, string(src), "// @BODY(\"newPhoto\")\n\tNewPhoto(newPhoto NewPhoto2) CreatePhotoRequestBuilder\n")
+	assert.Contains(t, string(src), "type Photo struct {\n\tNewPhoto2\n\tCreatedAt time.Time `json:\"created_at,omitempty\"`\n}\n")
+	assert.NotContains(t, string(src), "func NewPhoto(")
+
+	assert.Contains(t, string(src), "// @POST_FORM(\"/photos/{id}/comments\")\n")
+	assert.Contains(t, string(src), "// @PATH(\"id\")\n\tID(id int64) PostCommentRequestBuilder\n")
+	assert.Contains(t, string(src), "// @FIELD(\"type\")\n\tType(typeValue string) PostCommentRequestBuilder\n")
+	assert.Contains(t, string(src), "type PostCommentResponse struct{}\n")
+}
+
+func TestImportMethods(t *testing.T) {
+	body := &RequestBody{Content: map[string]*MediaType{
+		"application/json": {Schema: &Schema{Type: "object", Properties: map[string]*Schema{"name": {Type: "string"}}}},
+	}}
+	doc := &Document{
+		Paths: map[string]*PathItem{
+			"/photos": {
+				Options: &Operation{OperationID: "photoOptions", Responses: map[string]*Response{"204": {}}},
+				Patch:   &Operation{OperationID: "patchPhotos", RequestBody: body, Responses: map[string]*Response{"200": {}}},
+				Delete:  &Operation{OperationID: "deletePhotos", RequestBody: body, Responses: map[string]*Response{"200": {}}},
+				Trace:   &Operation{OperationID: "tracePhotos", Responses: map[string]*Response{"200": {}}},
+			},
+		},
+	}
+	src, err := Import(doc, "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, string(src), "// @OPTIONS(\"/photos\")\n// @SUCCESS(\"204\")\ntype PhotoOptionsRequestBuilder interface {\n")
+	assert.Contains(t, string(src), "// @PATCH(\"/photos\")\ntype PatchPhotosRequestBuilder interface {\n")
+	assert.Contains(t, string(src), "// @HTTP(method=\"DELETE\", path=\"/photos\", hasBody=true)\ntype DeletePhotosRequestBuilder interface {\n")
+	assert.Contains(t, string(src), "// @HTTP(method=\"TRACE\", path=\"/photos\")\ntype TracePhotosRequestBuilder interface {\n")
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "api.go", src, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		return
+	}
+	results, err := parse.NewParser(f, "test").Parse()
+	if assert.NoError(t, err) && assert.Len(t, results, 4) {
+		assert.Equal(t, "DELETE", results[0].HttpMethod)
+		assert.True(t, results[0].HasBody)
+		assert.Len(t, results[0].PostParams, 1)
+		assert.Equal(t, "TRACE", results[3].HttpMethod)
+		assert.False(t, results[3].HasBody)
+	}
+}
+
+func TestImportQueryFormat(t *testing.T) {
+	explode := false
+	strings := &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	doc := &Document{
+		Paths: map[string]*PathItem{
+			"/photos": {
+				Get: &Operation{
+					OperationID: "listPhotos",
+					Parameters: []*Parameter{
+						{Name: "tags", In: "query", Explode: &explode, Schema: strings},
+						{Name: "ids", In: "query", Schema: strings},
+					},
+					Responses: map[string]*Response{"200": {}},
+				},
+			},
+		},
+	}
+	src, err := Import(doc, "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, string(src), "// @QUERY(\"tags\", format=\"csv\")\n\tTags(tags []string) ListPhotosRequestBuilder\n")
+	assert.Contains(t, string(src), "// @QUERY(\"ids\")\n\tIDs(ids []string) ListPhotosRequestBuilder\n")
+}
+
+func TestImportMultipart(t *testing.T) {
+	doc := &Document{
+		Paths: map[string]*PathItem{
+			"/photos": {
+				Post: &Operation{
+					OperationID: "uploadPhoto",
+					RequestBody: &RequestBody{Content: map[string]*MediaType{
+						"multipart/form-data": {Schema: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"caption": {Type: "string"},
+								"photo":   {Type: "string", Format: "binary"},
+							},
+						}},
+					}},
+					Responses: map[string]*Response{"200": {}},
+				},
+			},
+		},
+	}
+	src, err := Import(doc, "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, string(src), "// @PART(\"caption\")\n\tCaption(caption string) UploadPhotoRequestBuilder\n")
+	assert.Contains(t, string(src), "// @PART(\"photo\")\n\tPhoto(photo io.Reader) UploadPhotoRequestBuilder\n")
+	assert.Contains(t, string(src), "\t\"io\"\n")
+}
+
+func TestImportUnsupported(t *testing.T) {
+	var testCases = []*PathItem{
+		{Get: &Operation{
+			Parameters: []*Parameter{{Name: "session", In: "cookie"}},
+			Responses:  map[string]*Response{"200": {}},
+		}},
+		{Get: &Operation{
+			Parameters: []*Parameter{{Ref: parametersPrefix + "Missing"}},
+			Responses:  map[string]*Response{"200": {}},
+		}},
+		{Post: &Operation{
+			RequestBody: &RequestBody{Content: map[string]*MediaType{"application/octet-stream": {}}},
+			Responses:   map[string]*Response{"200": {}},
+		}},
+	}
+
+	for _, item := range testCases {
+		_, err := Import(&Document{Paths: map[string]*PathItem{"/photos": item}}, "test")
+		assert.Error(t, err)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	var testCases = []struct {
+		input string
+		name  string
+		param string
+	}{
+		{"photo_id", "PhotoID", "photoID"},
+		{"photoId", "PhotoID", "photoID"},
+		{"photo_ids", "PhotoIDs", "photoIDs"},
+		{"X-Request-ID", "XRequestID", "xRequestID"},
+		{"get /photos/{id}", "GetPhotosID", "getPhotosID"},
+		{"type", "Type", "typeValue"},
+		{"b", "B", "b"},
+		{"2fa", "X2fa", "x2fa"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.name, goName(testCase.input))
+		assert.Equal(t, testCase.param, paramName(testCase.input))
+	}
+}
@@ -0,0 +1,64 @@
+package openapi
+
+// importTemplate is the layout of the source generated from an OpenAPI document.
+const importTemplate = `// REST API imported from the OpenAPI document {{ .Title }} {{ .Version }} with gorest import-openapi.
+// Generate the request builder implementations with gorest.
+
+package {{ .PackageName }}
+
+{{- if .Imports }}
+import (
+{{- range .Imports }}
+	"{{ . }}"
+{{- end }}
+)
+{{- end }}
+
+{{ range .Requests }}
+{{- $request := . }}
+{{- if .Summary }}
+// {{ .Name }} {{ .Summary }}
+{{- end }}
+{{- if .Generic }}
+// @HTTP(method="{{ .Method }}", path="{{ .Path }}"{{ if .HasBody }}, hasBody=true{{ end }})
+{{- else }}
+// @{{ .Method }}("{{ .Path }}")
+{{- end }}
+{{- if .SuccessCodes }}
+// @SUCCESS("{{ .SuccessCodes }}")
+{{- end }}
+type {{ .Name }} interface {
+{{- range .Params }}
+	// @{{ .Annotation }}("{{ .Value }}"{{ if .Format }}, format="{{ .Format }}"{{ end }})
+	{{ .Func }}({{ .Name }} {{ .Type }}) {{ $request.Name }}
+{{ end }}
+	// @SYNC("{{ .ResponseType }}")
+	Run() ({{ .ResponseType }}, error)
+}
+{{ end }}
+
+{{ range .Types }}
+{{- if .Fields }}
+type {{ .Name }} struct {
+{{- range .Fields }}
+	{{ .Name }} {{ .Type }} {{ .Tag }}
+{{- end }}
+}
+{{- else }}
+type {{ .Name }} {{ .Underlying }}
+{{- end }}
+{{ if .Constructor }}
+{{- if .Empty }}
+func New{{ .Name }}(r io.Reader) ({{ .Name }}, error) {
+	return {{ .Name }}{}, nil
+}
+{{- else }}
+func New{{ .Name }}(r io.Reader) ({{ .Name }}, error) {
+	var response {{ .Name }}
+	err := json.NewDecoder(r).Decode(&response)
+	return response, err
+}
+{{- end }}
+{{ end }}
+{{ end }}
+`
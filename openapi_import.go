@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/jsaund/gorest/openapi"
+)
+
+// importOpenAPI implements the import-openapi command which declares annotated request
+// builder interfaces for the operations of an OpenAPI 3 document.
+// Usage: gorest import-openapi [flags] file
+func importOpenAPI(args []string) {
+	flags := flag.NewFlagSet("import-openapi", flag.ExitOnError)
+	output := flags.String("output", "", "name of output file containing the request builder interfaces (if absent then Stdout is used)")
+	pkg := flags.String("pkg", "", "name of output file package")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: gorest import-openapi [flags] file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		fmt.Fprintln(os.Stderr, "Expects a single OpenAPI document in YAML or JSON")
+		os.Exit(1)
+	}
+
+	if *pkg == "" {
+		flags.Usage()
+		fmt.Fprintln(os.Stderr, "Expects valid package name")
+		os.Exit(1)
+	}
+
+	filename := flags.Arg(0)
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read OpenAPI document %s. %s\n", filename, err)
+		os.Exit(1)
+	}
+
+	doc, err := openapi.Decode(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to decode OpenAPI document %s. %s\n", filename, err)
+		os.Exit(1)
+	}
+
+	buf, err := openapi.Import(doc, *pkg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to import OpenAPI document %s. %s\n", filename, err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(buf)
+		return
+	}
+	if err := writeFile(*output, buf); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write REST API definition to file %s. Reason: %s\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Println("REST API definition written to file " + *output)
+}
@@ -0,0 +1,307 @@
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
+
+// restclientPath is the import path of the package declaring restclient.Part
+const restclientPath = "github.com/jsaund/gorest/restclient"
+
+// readerType is the method set of io.Reader
+var readerType = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "Read", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewParam(token.NoPos, nil, "p", types.NewSlice(types.Typ[types.Byte]))),
+		types.NewTuple(
+			types.NewParam(token.NoPos, nil, "n", types.Typ[types.Int]),
+			types.NewParam(token.NoPos, nil, "err", types.Universe.Lookup("error").Type()),
+		),
+		false,
+	)),
+}, nil).Complete()
+
+// NewPackageParser returns a Parser for a file belonging to a type checked package
+// which was loaded with fset.
+// Parameter and response types are resolved using the package's type information
+// which allows them to be declared in any file of the package.
+func NewPackageParser(fset *token.FileSet, file *ast.File, pkg *types.Package, info *types.Info) *Parser {
+	return &Parser{
+		fset:  fset,
+		file:  file,
+		info:  info,
+		types: pkg,
+		pkg:   pkg.Name(),
+	}
+}
+
+// ParsePackage loads the package in dir with full type information and parses every
+// request builder interface declared in any of its files.
+// Type errors are tolerated as the package usually references the generated
+// implementation which may not exist yet. Request builders which refer to types that
+// can not be resolved are reported by the parser. The problems found in all files are
+// returned together as a scanner.ErrorList.
+func ParsePackage(dir string) ([]*ParseResult, error) {
+	cfg := &packages.Config{
+		Mode: loadMode,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected a single package in %s, found %d", dir, len(pkgs))
+	}
+
+	pkg := pkgs[0]
+	var errs []string
+	for _, e := range pkg.Errors {
+		if e.Kind != packages.TypeError {
+			errs = append(errs, e.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load package %s:\n%s", dir, strings.Join(errs, "\n"))
+	}
+
+	var results []*ParseResult
+	var parseErrs scanner.ErrorList
+	for _, file := range pkg.Syntax {
+		r, err := NewPackageParser(pkg.Fset, file, pkg.Types, pkg.TypesInfo).Parse()
+		if list, ok := err.(scanner.ErrorList); ok {
+			parseErrs = append(parseErrs, list...)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		results = append(results, r...)
+	}
+	if len(parseErrs) > 0 {
+		parseErrs.Sort()
+		return nil, parseErrs
+	}
+	return results, nil
+}
+
+// validateTypes reports the types referenced by the request builder which do not
+// exist or can not be used to implement it.
+func (p *Parser) validateTypes(r *ParseResult) {
+	var fields []*ast.Field
+	if r.ServiceType == "" {
+		// The parameters of a service method are validated with the method
+		fields = append(fields, r.Params...)
+	}
+	if r.SyncResponse != nil {
+		fields = append(fields, r.SyncResponse)
+	}
+	if r.AsyncResponse != nil {
+		fields = append(fields, r.AsyncResponse)
+	}
+	for _, f := range fields {
+		p.validateSignature(r, f)
+	}
+
+	for _, f := range r.PostParams {
+		t := p.paramType(f, 0)
+		if t != nil && !isSerializable(t) {
+			p.errorf(f.Pos(), "%s: @%s parameter of %s has type %s which can not be serialized", r.displayName(), body, f.Names[0].Name, t)
+		}
+	}
+
+	// Every other parameter is sent as text, see restclient.ParamValues
+	for _, f := range r.Params {
+		annotation, _ := ExtractRequestAnnotation(f.Doc.Text())
+		if annotation.Key == body {
+			continue
+		}
+		for i := range f.Type.(*ast.FuncType).Params.List {
+			t := p.paramType(f, i)
+			if t != nil && t != types.Typ[types.Invalid] && !isFormattable(t, annotation.Key == part) {
+				p.errorf(f.Pos(), "%s: @%s parameter of %s has type %s which can not be formatted as text", r.displayName(), annotation.Key, f.Names[0].Name, t)
+			}
+		}
+	}
+
+	if r.SyncResponse != nil && r.ResponseType != "" {
+		p.resolveResponse(r)
+	}
+}
+
+// validateSignature reports the parameter and result types of the method which do not resolve.
+func (p *Parser) validateSignature(r *ParseResult, f *ast.Field) {
+	fn, ok := f.Type.(*ast.FuncType)
+	if !ok {
+		return
+	}
+	lists := []*ast.FieldList{fn.Params, fn.Results}
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, param := range list.List {
+			if r.CallbackType != "" && types.ExprString(param.Type) == r.CallbackType {
+				// The callback is declared by the generated implementation
+				continue
+			}
+			t := p.info.TypeOf(param.Type)
+			if t == nil || t == types.Typ[types.Invalid] {
+				p.errorf(param.Type.Pos(), "%s: %s refers to undefined type %s", r.displayName(), f.Names[0].Name, types.ExprString(param.Type))
+			}
+		}
+	}
+}
+
+// resolveResponse decides how the response of the request is created. A slice or map
+// response, which has no constructor, is decoded by the converter. Otherwise a
+// New<Response> constructor declared in the package of the response takes precedence
+// and a struct or pointer to struct response is decoded by the converter. Any other
+// response requires the constructor.
+func (p *Parser) resolveResponse(r *ParseResult) {
+	if isCollectionResponse(r.ResponseType) {
+		r.DecodeResponse = true
+		return
+	}
+	name := r.ResponseConstructor()
+	response := p.responseType(r)
+	if fn := responseConstructor(response); fn != nil {
+		p.validateResponseConstructor(r, fn, response)
+		return
+	}
+	if response != nil && isStructResponse(response) {
+		r.DecodeResponse = true
+		return
+	}
+	p.errorf(r.SyncResponse.Pos(), "%s: response %s is not a struct, a slice or a map, or a pointer to one, and requires the constructor func %s(io.Reader) (%s, error)", r.displayName(), r.ResponseType, name, r.ResponseType)
+}
+
+// validateResponseConstructor reports whether the constructor fn has the signature
+// func(io.Reader) (<Response>, error).
+func (p *Parser) validateResponseConstructor(r *ParseResult, fn *types.Func, response types.Type) {
+	sig := fn.Type().(*types.Signature)
+	valid := sig.Params().Len() == 1 && sig.Results().Len() == 2 && isReaderParam(sig.Params().At(0).Type())
+	if valid {
+		valid = types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type())
+	}
+	if valid && response != nil {
+		valid = types.Identical(sig.Results().At(0).Type(), response)
+	}
+	if !valid {
+		p.errorf(r.SyncResponse.Pos(), "%s: constructor %s has signature %s, expected func(io.Reader) (%s, error)", r.displayName(), fn.Name(), sig, r.ResponseType)
+	}
+}
+
+// responseType returns the type of the response returned by the method executing the
+// request, or nil if it does not resolve.
+func (p *Parser) responseType(r *ParseResult) types.Type {
+	fn, ok := r.SyncResponse.Type.(*ast.FuncType)
+	if !ok {
+		return nil
+	}
+	results := fieldTypes(fn.Results)
+	if len(results) == 0 {
+		return nil
+	}
+	t := p.info.TypeOf(results[0])
+	if t == nil || t == types.Typ[types.Invalid] {
+		return nil
+	}
+	return t
+}
+
+// responseConstructor returns the New<Response> func declared in the package of the
+// named response, which may be a pointer to the named type, or nil if there is none.
+func responseConstructor(response types.Type) *types.Func {
+	if ptr, ok := response.(*types.Pointer); ok {
+		response = ptr.Elem()
+	}
+	named, ok := response.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+	fn, _ := named.Obj().Pkg().Scope().Lookup("New" + named.Obj().Name()).(*types.Func)
+	return fn
+}
+
+// paramType returns the type of the i-th parameter of the method or nil if the method
+// has no such parameter.
+func (p *Parser) paramType(f *ast.Field, i int) types.Type {
+	fn, ok := f.Type.(*ast.FuncType)
+	if !ok || i >= len(fn.Params.List) {
+		return nil
+	}
+	return p.info.TypeOf(fn.Params.List[i].Type)
+}
+
+// isReaderParam reports whether an io.Reader can be passed to a parameter of type t.
+func isReaderParam(t types.Type) bool {
+	return types.AssignableTo(readerType, t)
+}
+
+// isStructResponse reports whether t is a struct or a pointer to a struct, which can be
+// decoded by a converter.
+func isStructResponse(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
+// isFormattable reports whether a parameter of type t can be formatted by
+// restclient.ParamValues. Text marshalers, stringers, booleans, numbers and strings are
+// formatted, as are the elements of pointers, slices and arrays. Interfaces are formatted
+// by their dynamic value. A multipart parameter may also be a reader or a restclient.Part,
+// see restclient.NewParts.
+func isFormattable(t types.Type, part bool) bool {
+	if part && (types.Implements(t, readerType) || isPartType(t)) {
+		return true
+	}
+	methods := types.NewMethodSet(t)
+	if methods.Lookup(nil, "MarshalText") != nil || methods.Lookup(nil, "String") != nil {
+		return true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&types.IsComplex == 0 && u.Kind() != types.UnsafePointer
+	case *types.Pointer:
+		return isFormattable(u.Elem(), part)
+	case *types.Slice:
+		return isFormattable(u.Elem(), part)
+	case *types.Array:
+		return isFormattable(u.Elem(), part)
+	case *types.Interface:
+		return true
+	}
+	return false
+}
+
+// isPartType reports whether t is restclient.Part.
+func isPartType(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == restclientPath && obj.Name() == "Part"
+}
+
+// isSerializable reports whether a value of type t can be marshalled in to a request body.
+func isSerializable(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Chan, *types.Signature:
+		return false
+	case *types.Basic:
+		return u.Info()&types.IsComplex == 0 && u.Kind() != types.UnsafePointer
+	case *types.Pointer:
+		return isSerializable(u.Elem())
+	}
+	return true
+}
@@ -1,56 +1,147 @@
 package parse
 
 import (
+	"fmt"
 	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
 	"go/types"
+	"mime"
+	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
 )
 
 const (
 	sync               string = "SYNC"
+	client             string = "CLIENT"
+	converter          string = "CONVERTER"
+	headers            string = "HEADERS"
 	async              string = "ASYNC"
+	body               string = "BODY"
 	header             string = "HEADER"
 	path               string = "PATH"
 	query              string = "QUERY"
 	field              string = "FIELD"
 	part               string = "PART"
+	dynamicURL         string = "URL"
+	success            string = "SUCCESS"
+	retry              string = "RETRY"
 	httpMethodGet      string = "GET"
 	httpMethodPost     string = "POST"
 	httpMethodPostForm string = "POST_FORM"
 	httpMethodPut      string = "PUT"
 	httpMethodDelete   string = "DELETE"
 	httpMethodHead     string = "HEAD"
+	httpMethodPatch    string = "PATCH"
+	httpMethodOptions  string = "OPTIONS"
+	httpMethodGeneric  string = "HTTP"
+
+	// Named arguments of the generic @HTTP annotation
+	argMethod  string = "method"
+	argPath    string = "path"
+	argHasBody string = "hasBody"
+
+	// Named arguments of the @QUERY and @FIELD annotations
+	argFormat    string = "format"
+	argOmitEmpty string = "omitempty"
+	formatCSV    string = "csv"
+	formatMulti  string = "multi"
+
+	// Named argument of the @PATH and @QUERY annotations for values which are
+	// already URL encoded
+	argEncoded string = "encoded"
+
+	// Named arguments of the @PART annotation
+	argFileName    string = "filename"
+	argContentType string = "contentType"
 
 	// pattern represents the annotation regex pattern
 	// A valid annotation example is: @GET("/photos/{id}/comments"), where we return
-	// ['GET("/photos/{id}/comments")', 'GET', '/photos/{id}/comments']
-	pattern string = `@(\w+)\(\"(.*)\"\)`
+	// ['GET("/photos/{id}/comments")', 'GET', '"/photos/{id}/comments"']
+	pattern string = `@(\w+)\(((?:"(?:[^"\\]|\\.)*"|[^"()])*)\)`
+
+	// argPattern represents a single annotation argument regex pattern
+	// The arguments of an annotation are an optional quoted value followed by named
+	// arguments, for example: "/files/{id}", hasBody=true
+	argPattern string = `^\s*(?:(\w+)\s*=\s*)?(?:"((?:[^"\\]|\\.)*)"|(\w+))\s*(?:,|$)`
 )
 
 var re *regexp.Regexp = regexp.MustCompile(pattern)
 
+var argRe *regexp.Regexp = regexp.MustCompile(argPattern)
+
+// placeholderRe matches the path placeholders of an endpoint, for example {id}
+var placeholderRe *regexp.Regexp = regexp.MustCompile(`\{([^{}/]+)\}`)
+
 var annotationTypes = map[string]empty{
-	field:  empty{},
-	header: empty{},
-	part:   empty{},
-	path:   empty{},
-	query:  empty{},
-	sync:   empty{},
-	async:  empty{},
+	body:       empty{},
+	field:      empty{},
+	header:     empty{},
+	part:       empty{},
+	path:       empty{},
+	query:      empty{},
+	dynamicURL: empty{},
+	sync:       empty{},
+	async:      empty{},
 }
 
+var interfaceAnnotationTypes = map[string]empty{
+	client:    empty{},
+	converter: empty{},
+	headers:   empty{},
+	retry:     empty{},
+	success:   empty{},
+}
+
+// multiValueAnnotations are the annotations accepting several unnamed, quoted values
+var multiValueAnnotations = map[string]empty{
+	headers: empty{},
+}
+
+// headerNamePattern matches a header name, which is an HTTP token
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
 var httpMethods = map[string]empty{
 	httpMethodDelete:   empty{},
+	httpMethodGeneric:  empty{},
 	httpMethodGet:      empty{},
 	httpMethodHead:     empty{},
+	httpMethodOptions:  empty{},
+	httpMethodPatch:    empty{},
 	httpMethodPost:     empty{},
 	httpMethodPostForm: empty{},
 	httpMethodPut:      empty{},
 }
 
+// bodyMethods are the HTTP methods which send a request body
+var bodyMethods = map[string]empty{
+	httpMethodPatch: empty{},
+	httpMethodPost:  empty{},
+	httpMethodPut:   empty{},
+}
+
+// annotationArgs are the named arguments accepted by the request annotations
+var annotationArgs = map[string]map[string]func(string) error{
+	field: {argFormat: validateFormat, argOmitEmpty: validateBool},
+	path:  {argEncoded: validateBool},
+	query: {argFormat: validateFormat, argOmitEmpty: validateBool, argEncoded: validateBool},
+	part:  {argFileName: validateFileName, argContentType: validateContentType},
+}
+
+// methodPattern matches the method of the generic @HTTP annotation
+var methodPattern = regexp.MustCompile(`^[A-Za-z]+$`)
+
 type Annotation struct {
 	Key   string
 	Value string
+	// Values holds every unnamed value of an annotation accepting several, such as
+	// @HEADERS. Value is then the first of them.
+	Values []string
+	// Args holds the named arguments of the annotation, for example hasBody=true
+	Args map[string]string
 }
 
 type annotationFilter func(key string) bool
@@ -58,42 +149,86 @@ type annotationFilter func(key string) bool
 type empty struct{}
 
 type ParseResult struct {
-	PackageName         string
-	RequestType         string
-	ApiEndpoint         string
-	HttpMethod          string
-	PathSubstitutions   map[string]*ast.Field
-	QueryParams         map[string]*ast.Field
-	PostFormParams      map[string]*ast.Field
-	PostMultiPartParams map[string]*ast.Field
-	PostParams          map[string]*ast.Field
-	HeaderParams        map[string]*ast.Field
+	PackageName string
+	RequestType string
+	ClientName  string
+	// ConverterName is the name of the restclient.Converter the request bodies are
+	// converted with, the client's converter is used when it is empty
+	ConverterName string
+	ApiEndpoint   string
+	HttpMethod    string
+	HasBody       bool
+	// PathPlaceholders are the names of the placeholders of ApiEndpoint in the order
+	// they appear, every placeholder is substituted by exactly one @PATH method
+	PathPlaceholders []string
+	// Params are the methods supplying a request parameter in the order they are
+	// declared. The methods of each kind of parameter are also held separately below,
+	// again in the order they are declared. URLParam is the method supplying the URL
+	// which replaces the endpoint, see restclient.ResolveURL.
+	Params              []*ast.Field
+	PathSubstitutions   []*ast.Field
+	QueryParams         []*ast.Field
+	PostFormParams      []*ast.Field
+	PostMultiPartParams []*ast.Field
+	PostParams          []*ast.Field
+	HeaderParams        []*ast.Field
+	URLParam            *ast.Field
 	SyncResponse        *ast.Field
+	SyncContext         bool
 	AsyncResponse       *ast.Field
+	AsyncContext        bool
 	CallbackType        string
 	ResponseType        string
+	SuccessCodes        []int
+	RetryAttempts       int
+	// DecodeResponse is set when the response is a struct or a pointer to a struct
+	// without a New<Response> constructor. The generated implementation then decodes
+	// the response with the request's converter instead of calling the constructor.
+	DecodeResponse bool
+	// Headers are the static headers declared by @HEADERS, keyed by their canonical
+	// name. The headers supplied by @HEADER methods are added to them.
+	Headers http.Header
+	// ServiceType is the name of the service interface declaring the request as one of
+	// its methods and is empty for request builder interfaces. SyncResponse is then the
+	// method of the service, which sends the request, and Params are its parameters.
+	ServiceType string
+	// Imports are the imports of the file declaring the request, which its parameter
+	// and response types may refer to.
+	Imports []*ast.ImportSpec
+	// Types and TypesInfo hold the type information of the package declaring the
+	// request builder. They are only available when the package has been loaded
+	// with ParsePackage and are nil otherwise.
+	Types     *types.Package
+	TypesInfo *types.Info
 }
 
 func newParseResult(pkg string) *ParseResult {
 	return &ParseResult{
-		PackageName:         pkg,
-		PathSubstitutions:   make(map[string]*ast.Field),
-		QueryParams:         make(map[string]*ast.Field),
-		PostFormParams:      make(map[string]*ast.Field),
-		PostMultiPartParams: make(map[string]*ast.Field),
-		PostParams:          make(map[string]*ast.Field),
-		HeaderParams:        make(map[string]*ast.Field),
+		PackageName: pkg,
 	}
 }
 
+// Parser walks a file and collects the request builder interfaces it declares.
+// Every problem found in the file is reported, see Parse.
 type Parser struct {
-	file         *ast.File
-	info         *types.Info
-	result       *ParseResult
-	buildRequest bool
+	fset    *token.FileSet
+	file    *ast.File
+	info    *types.Info
+	types   *types.Package
+	pkg     string
+	results []*ParseResult
+	errs    scanner.ErrorList
 }
 
+// NewParser returns a Parser for the file. The errors it reports have no position,
+// use NewParserWithFileSet to report the position of every error.
 func NewParser(file *ast.File, pkg string) *Parser {
+	return NewParserWithFileSet(nil, file, pkg)
+}
+
+// NewParserWithFileSet returns a Parser for the file which was parsed with fset.
+// The errors it reports are positioned in the file.
+func NewParserWithFileSet(fset *token.FileSet, file *ast.File, pkg string) *Parser {
 	info := &types.Info{
 		Types: make(map[ast.Expr]types.TypeAndValue),
 		Defs:  make(map[*ast.Ident]types.Object),
@@ -101,15 +236,43 @@ func NewParser(file *ast.File, pkg string) *Parser {
 	}
 
 	return &Parser{
-		file:   file,
-		info:   info,
-		result: newParseResult(pkg),
+		fset: fset,
+		file: file,
+		info: info,
+		pkg:  pkg,
 	}
 }
 
-func (p *Parser) Parse() *ParseResult {
+// Parse walks the file and returns one ParseResult per request builder interface,
+// in the order the interfaces are declared. If any request builder interface is not
+// valid the error is a scanner.ErrorList holding every problem found in the file.
+func (p *Parser) Parse() ([]*ParseResult, error) {
+	p.results = nil
+	p.errs = nil
 	ast.Walk(p, p.file)
-	return p.result
+	for _, r := range p.results {
+		if p.types != nil {
+			p.validateTypes(r)
+		} else if r.SyncResponse != nil && r.ResponseType != "" {
+			r.DecodeResponse = p.decodeResponse(r)
+		}
+	}
+	if len(p.errs) > 0 {
+		if p.fset != nil {
+			p.errs.Sort()
+		}
+		return nil, p.errs
+	}
+	return p.results, nil
+}
+
+// errorf reports a problem found at pos.
+func (p *Parser) errorf(pos token.Pos, format string, args ...interface{}) {
+	var position token.Position
+	if p.fset != nil && pos.IsValid() {
+		position = p.fset.Position(pos)
+	}
+	p.errs.Add(position, fmt.Sprintf(format, args...))
 }
 
 func (p *Parser) Visit(node ast.Node) ast.Visitor {
@@ -118,76 +281,596 @@ func (p *Parser) Visit(node ast.Node) ast.Visitor {
 	}
 
 	switch node.(type) {
-	case *ast.File:
-		// At the start of a file
-		// Reset builder flags
-		p.buildRequest = false
-		break
-	case *ast.TypeSpec:
-		// Check if we are at the beginning of a request builder declaration
-		// or a response / callback declaration
-		// This must be an interface
-		typeSpec := node.(*ast.TypeSpec)
-		switch typeSpec.Type.(type) {
-		case *ast.InterfaceType:
-			if p.buildRequest {
-				p.result.RequestType = typeSpec.Name.Name
-			}
-			break
-		}
-		break
-	case *ast.InterfaceType:
-		if !p.buildRequest {
-			// Only interested in parsing methods of the Requeset interface
-			// Ignore all other types
-			break
-		}
-		// Retain a mapping of interface methods to their fields which contain
-		// the query parameter and argument name and type information to implement
-		// the interface
-		ifc := node.(*ast.InterfaceType)
-		methods := ifc.Methods
-		for _, f := range methods.List {
-			annotation, valid := ExtractRequestAnnotation(f.Doc.List[0].Text)
-			if !valid {
+	case *ast.GenDecl:
+		// Check if we are at the beginning of a request builder declaration.
+		// A request builder must be an interface annotated with an HTTP method.
+		// The annotation is attached to the declaration for a single type and
+		// to the type spec within a grouped type declaration.
+		decl := node.(*ast.GenDecl)
+		for _, spec := range decl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
 				continue
 			}
-			param := f.Names[0].Name
-
-			switch annotation.Key {
-			case field:
-				p.result.PostFormParams[param] = f
-			case header:
-				p.result.HeaderParams[param] = f
-			case part:
-				p.result.PostMultiPartParams[param] = f
-			case path:
-				p.result.PathSubstitutions[param] = f
-			case query:
-				p.result.QueryParams[param] = f
-			case sync:
-				p.result.SyncResponse = f
-				p.result.ResponseType = annotation.Value
-			case async:
-				p.result.AsyncResponse = f
-				p.result.CallbackType = annotation.Value
-			}
-		}
-		break
-	case *ast.Comment:
-		comment := node.(*ast.Comment)
-		if annotation, valid := ExtractHttpAnnotation(comment.Text); valid {
-			// Extract the HTTP Method and API from the Interface declaration
-			p.buildRequest = true
-			p.result.HttpMethod = annotation.Key
-			p.result.ApiEndpoint = annotation.Value
+			ifc, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			doc := typeSpec.Doc
+			if doc == nil {
+				doc = decl.Doc
+			}
+			_, annotated := extractHttpAnnotationFromDoc(doc)
+			if !annotated && !isService(ifc) {
+				continue
+			}
+			if typeSpec.TypeParams != nil {
+				p.errorf(typeSpec.Pos(), "%s: type parameters are not supported, the types of a request must be known to generate it", typeSpec.Name.Name)
+				continue
+			}
+			if annotated {
+				p.results = append(p.results, p.parseRequest(typeSpec.Name.Name, doc, ifc))
+			} else {
+				p.results = append(p.results, p.parseService(typeSpec.Name.Name, doc, ifc)...)
+			}
 		}
-		break
+		// The request builder declarations have been fully parsed
+		return nil
 	}
 
 	return p
 }
 
+// parseRequest builds the ParseResult for a single request builder interface.
+func (p *Parser) parseRequest(name string, doc *ast.CommentGroup, ifc *ast.InterfaceType) *ParseResult {
+	result := p.newResult(name)
+
+	// Interface annotations apply to the request as a whole
+	httpPos := p.interfaceAnnotations(result, name, p.annotations(name, doc))
+
+	// Retain a mapping of interface methods to their fields which contain
+	// the query parameter and argument name and type information to implement
+	// the interface
+	pathValues := make(map[string]string)
+	for _, f := range ifc.Methods.List {
+		if len(f.Names) == 0 {
+			p.errorf(f.Pos(), "%s: embedded interface %s is not supported, every method must be annotated", name, types.ExprString(f.Type))
+			continue
+		}
+		param := f.Names[0].Name
+		annotation, ok := p.methodAnnotation(name, f)
+		if !ok {
+			continue
+		}
+		if err := validateArgs(annotation); err != nil {
+			p.errorf(f.Pos(), "%s.%s: %s", name, param, err)
+		}
+
+		switch annotation.Key {
+		case sync:
+			if result.SyncResponse != nil {
+				p.errorf(f.Pos(), "%s.%s: only one @%s annotation may be declared per request", name, param, sync)
+			}
+			p.validateSync(name, f, annotation)
+			result.SyncResponse = f
+			result.SyncContext = hasContextParam(f)
+			result.ResponseType = annotation.Value
+		case async:
+			if result.AsyncResponse != nil {
+				p.errorf(f.Pos(), "%s.%s: only one @%s annotation may be declared per request", name, param, async)
+			}
+			p.validateAsync(name, f, annotation)
+			result.AsyncResponse = f
+			result.AsyncContext = hasContextParam(f)
+			result.CallbackType = annotation.Value
+		default:
+			p.addParam(result, name, f, annotation, pathValues)
+		}
+	}
+
+	p.validatePath(result, name, httpPos, pathValues)
+	if result.AsyncResponse != nil && result.SyncResponse == nil {
+		p.errorf(result.AsyncResponse.Pos(), "%s: @%s requires a @%s method declaring the response type", name, async, sync)
+	}
+	return result
+}
+
+// validateSync reports a @SYNC method whose signature is not the signature of its
+// implementation, which accepts an optional context.Context and returns the response
+// declared by the annotation and an error.
+func (p *Parser) validateSync(name string, f *ast.Field, annotation Annotation) {
+	method := f.Names[0].Name
+	if annotation.Value == "" {
+		p.errorf(f.Pos(), "%s.%s: invalid @%s annotation: the response type must not be empty", name, method, sync)
+		return
+	}
+	fn := f.Type.(*ast.FuncType)
+	if params := fieldTypes(fn.Params); len(params) > 1 || len(params) == 1 && !hasContextParam(f) {
+		p.errorf(f.Pos(), "%s.%s: a @%s method accepts no parameter other than a context.Context", name, method, sync)
+	}
+	results := fieldTypes(fn.Results)
+	if len(results) != 2 || types.ExprString(results[0]) != annotation.Value || types.ExprString(results[1]) != "error" {
+		p.errorf(f.Pos(), "%s.%s: a @%s method must return its response and an error, (%s, error)", name, method, sync, annotation.Value)
+	}
+}
+
+// validateAsync reports an @ASYNC method whose signature is not the signature of its
+// implementation, which accepts an optional context.Context followed by the callback
+// declared by the annotation and returns nothing.
+func (p *Parser) validateAsync(name string, f *ast.Field, annotation Annotation) {
+	method := f.Names[0].Name
+	if annotation.Value == "" {
+		p.errorf(f.Pos(), "%s.%s: invalid @%s annotation: the callback type must not be empty", name, method, async)
+		return
+	}
+	fn := f.Type.(*ast.FuncType)
+	params := fieldTypes(fn.Params)
+	n := 1
+	if hasContextParam(f) {
+		n = 2
+	}
+	if len(params) != n || types.ExprString(params[n-1]) != annotation.Value || fn.Results.NumFields() > 0 {
+		p.errorf(f.Pos(), "%s.%s: an @%s method must accept the callback and return nothing, for example %s(callback %s)", name, method, async, method, annotation.Value)
+	}
+}
+
+// newResult returns an empty ParseResult for the request, which holds the type
+// information of the package when it is available.
+func (p *Parser) newResult(requestType string) *ParseResult {
+	result := newParseResult(p.pkg)
+	result.Imports = p.file.Imports
+	if p.types != nil {
+		result.Types = p.types
+		result.TypesInfo = p.info
+	}
+	result.RequestType = requestType
+	return result
+}
+
+// interfaceAnnotations applies the annotations of the request as a whole to the result
+// and returns the position of its HTTP method annotation, which is not valid when the
+// request has none.
+func (p *Parser) interfaceAnnotations(result *ParseResult, name string, annotations []positionedAnnotation) token.Pos {
+	var httpPos token.Pos
+	for _, a := range annotations {
+		annotation, pos := a.Annotation, a.pos
+		if httpAnnotationFilter(annotation.Key) {
+			if httpPos.IsValid() {
+				p.errorf(pos, "%s: only one HTTP method annotation may be declared per request, found @%s", name, annotation.Key)
+				continue
+			}
+			httpPos = pos
+			if annotation.Key == httpMethodPostForm {
+				annotation.Key = httpMethodPost
+			}
+			if err := result.setHttpMethod(annotation); err != nil {
+				p.errorf(pos, "%s: %s", name, err)
+			}
+			continue
+		}
+		switch annotation.Key {
+		case client:
+			result.ClientName = annotation.Value
+		case headers:
+			if err := result.addHeaders(annotation.Values); err != nil {
+				p.errorf(pos, "%s: invalid @%s annotation: %s", name, headers, err)
+			}
+		case converter:
+			if annotation.Value == "" {
+				p.errorf(pos, "%s: invalid @%s annotation: the converter name must not be empty", name, converter)
+				continue
+			}
+			result.ConverterName = annotation.Value
+		case retry:
+			attempts, err := strconv.Atoi(annotation.Value)
+			if err != nil || attempts < 1 {
+				p.errorf(pos, "%s: invalid @%s annotation: %q is not a positive number of attempts", name, retry, annotation.Value)
+				continue
+			}
+			result.RetryAttempts = attempts
+		case success:
+			codes, err := parseStatusCodes(annotation.Value)
+			if err != nil {
+				p.errorf(pos, "%s: invalid @%s annotation: %s", name, success, err)
+				continue
+			}
+			result.SuccessCodes = codes
+		default:
+			p.errorf(pos, "%s: @%s annotates a method of the request builder, not the interface", name, annotation.Key)
+		}
+	}
+	return httpPos
+}
+
+// addParam adds the method f supplying a request parameter to the result. pathValues
+// maps the path placeholders substituted so far to the name of their method.
+func (p *Parser) addParam(result *ParseResult, name string, f *ast.Field, annotation Annotation, pathValues map[string]string) {
+	param := f.Names[0].Name
+	result.Params = append(result.Params, f)
+
+	// The value of the parameter is the argument of the method. The arguments of a method
+	// accepting several values, such as Tags(a, b string), are sent like a slice
+	fn := f.Type.(*ast.FuncType)
+	switch n := fn.Params.NumFields(); {
+	case n == 0:
+		p.errorf(f.Pos(), "%s.%s: the method must accept the value of the @%s parameter, for example %s(value string)", name, param, annotation.Key, param)
+	case n > 1 && (annotation.Key == body || annotation.Key == dynamicURL):
+		p.errorf(f.Pos(), "%s.%s: the method must accept only the value of the @%s parameter, found %d parameters", name, param, annotation.Key, n)
+	}
+	for _, v := range fn.Params.List {
+		for _, n := range v.Names {
+			if n.Name == "_" {
+				p.errorf(n.Pos(), "%s.%s: the value of the @%s parameter must be named, found _", name, param, annotation.Key)
+			}
+		}
+	}
+
+	switch annotation.Key {
+	case body:
+		if len(result.PostParams) > 0 {
+			p.errorf(f.Pos(), "%s.%s: only one @%s annotation may be declared per request", name, param, body)
+		}
+		if !result.HasBody {
+			p.errorf(f.Pos(), "%s.%s: @%s is not supported for %s requests", name, param, body, result.HttpMethod)
+		}
+		if len(result.PostFormParams) > 0 || len(result.PostMultiPartParams) > 0 {
+			p.errorf(f.Pos(), "%s.%s: @%s may not be declared with @%s or @%s, which are sent as the body", name, param, body, field, part)
+		}
+		result.PostParams = append(result.PostParams, f)
+	case field, part:
+		if !result.HasBody {
+			p.errorf(f.Pos(), "%s.%s: @%s is not supported for %s requests", name, param, annotation.Key, result.HttpMethod)
+		}
+		if len(result.PostParams) > 0 {
+			p.errorf(f.Pos(), "%s.%s: @%s may not be declared with @%s, which is sent as the body", name, param, annotation.Key, body)
+		}
+		if annotation.Key == field {
+			result.PostFormParams = append(result.PostFormParams, f)
+		} else {
+			result.PostMultiPartParams = append(result.PostMultiPartParams, f)
+		}
+	case header:
+		if !headerNamePattern.MatchString(annotation.Value) {
+			p.errorf(f.Pos(), "%s.%s: invalid @%s annotation: %q is not a header name", name, param, header, annotation.Value)
+		}
+		result.HeaderParams = append(result.HeaderParams, f)
+	case path:
+		if other, ok := pathValues[annotation.Value]; ok {
+			p.errorf(f.Pos(), "%s.%s: path placeholder {%s} is already substituted by %s", name, param, annotation.Value, other)
+		}
+		result.PathSubstitutions = append(result.PathSubstitutions, f)
+		pathValues[annotation.Value] = param
+	case query:
+		result.QueryParams = append(result.QueryParams, f)
+	case dynamicURL:
+		if annotation.Value != "" {
+			p.errorf(f.Pos(), "%s.%s: invalid @%s annotation: the URL is the value of the parameter, found %q", name, param, dynamicURL, annotation.Value)
+		}
+		if result.URLParam != nil {
+			p.errorf(f.Pos(), "%s.%s: only one @%s annotation may be declared per request", name, param, dynamicURL)
+		}
+		result.URLParam = f
+	}
+}
+
+// validatePath reports the placeholders of the endpoint which are not substituted by a
+// @PATH parameter and the @PATH parameters which have no placeholder.
+func (p *Parser) validatePath(result *ParseResult, name string, httpPos token.Pos, pathValues map[string]string) {
+	placeholders := make(map[string]bool)
+	for _, placeholder := range placeholderRe.FindAllStringSubmatch(result.ApiEndpoint, -1) {
+		key := placeholder[1]
+		if placeholders[key] {
+			continue
+		}
+		placeholders[key] = true
+		result.PathPlaceholders = append(result.PathPlaceholders, key)
+		if _, ok := pathValues[key]; !ok {
+			p.errorf(httpPos, "%s: path placeholder %s has no matching @%s annotation", name, placeholder[0], path)
+		}
+	}
+	for _, f := range result.PathSubstitutions {
+		annotation, _ := ExtractRequestAnnotation(f.Doc.Text())
+		if !placeholders[annotation.Value] {
+			p.errorf(f.Pos(), "%s.%s: @%s(%q) has no matching placeholder in %q", name, f.Names[0].Name, path, annotation.Value, result.ApiEndpoint)
+		}
+	}
+}
+
+// methodAnnotation returns the request annotation of a request builder method. Methods
+// without exactly one request annotation are reported.
+func (p *Parser) methodAnnotation(name string, f *ast.Field) (Annotation, bool) {
+	method := f.Names[0].Name
+	if f.Doc == nil {
+		p.errorf(f.Pos(), "%s.%s: missing doc comment, every method must be annotated with a request annotation such as @QUERY or @SYNC", name, method)
+		return Annotation{}, false
+	}
+
+	var annotations []Annotation
+	reported := len(p.errs)
+	for _, a := range p.annotations(name+"."+method, f.Doc) {
+		if !requestAnnotationFilter(a.Key) {
+			p.errorf(a.pos, "%s.%s: @%s annotates the request builder interface, not a method", name, method, a.Key)
+			continue
+		}
+		annotations = append(annotations, a.Annotation)
+	}
+	switch len(annotations) {
+	case 0:
+		if len(p.errs) == reported {
+			p.errorf(f.Pos(), "%s.%s: missing request annotation such as @QUERY or @SYNC", name, method)
+		}
+		return Annotation{}, false
+	case 1:
+		return annotations[0], true
+	}
+	p.errorf(f.Pos(), "%s.%s: only one request annotation may be declared per method, found %d", name, method, len(annotations))
+	return Annotation{}, false
+}
+
+// positionedAnnotation is an annotation and the position of the comment declaring it.
+type positionedAnnotation struct {
+	Annotation
+	pos token.Pos
+}
+
+// annotations returns the annotations declared by the doc comment of owner. Malformed
+// and unknown annotations are reported.
+func (p *Parser) annotations(owner string, doc *ast.CommentGroup) []positionedAnnotation {
+	if doc == nil {
+		return nil
+	}
+	var annotations []positionedAnnotation
+	for _, comment := range doc.List {
+		annotation, found, err := parseAnnotation(comment.Text)
+		if !found {
+			continue
+		}
+		if err != nil {
+			p.errorf(comment.Pos(), "%s: %s", owner, err)
+			continue
+		}
+		if !httpAnnotationFilter(annotation.Key) && !requestAnnotationFilter(annotation.Key) && !interfaceAnnotationFilter(annotation.Key) {
+			p.errorf(comment.Pos(), "%s: unknown annotation @%s", owner, annotation.Key)
+			continue
+		}
+		annotations = append(annotations, positionedAnnotation{annotation, comment.Pos()})
+	}
+	return annotations
+}
+
+// ResponseConstructor returns the name of the constructor of the response, which is
+// named after the response type without its pointer, for example NewPhotoResponse. The
+// constructor of a type declared in another package is qualified with the package, for
+// example models.NewPhoto.
+func (r *ParseResult) ResponseConstructor() string {
+	name := strings.TrimPrefix(r.ResponseType, "*")
+	expr, err := parser.ParseExpr(name)
+	if err != nil {
+		return "New" + name
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		return types.ExprString(sel.X) + ".New" + sel.Sel.Name
+	}
+	return "New" + name
+}
+
+// decodeResponse reports whether the response of the request is decoded by the
+// converter when the file is parsed without type information. A slice or map response
+// is always decoded. Otherwise the response, or the type it points to, is decoded when
+// it is a struct declared in the file and its constructor is not. A response which is
+// not declared in the file must have a constructor declared in another file.
+func (p *Parser) decodeResponse(r *ParseResult) bool {
+	if isCollectionResponse(r.ResponseType) {
+		return true
+	}
+	name := strings.TrimPrefix(r.ResponseType, "*")
+	isStruct := false
+	for _, decl := range p.file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.Name == r.ResponseConstructor() {
+				return false
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == name {
+					_, isStruct = typeSpec.Type.(*ast.StructType)
+				}
+			}
+		}
+	}
+	return isStruct
+}
+
+// isCollectionResponse reports whether the response is a slice, an array or a map, or a
+// pointer to one. It has no constructor as its type has no name.
+func isCollectionResponse(responseType string) bool {
+	expr, err := parser.ParseExpr(responseType)
+	if err != nil {
+		return false
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch expr.(type) {
+	case *ast.ArrayType, *ast.MapType:
+		return true
+	}
+	return false
+}
+
+// displayName returns the name the request is reported with, which is the name of the
+// request builder or of the service method sending the request, for example
+// PhotoService.ListPhotos.
+func (r *ParseResult) displayName() string {
+	if r.ServiceType != "" && r.SyncResponse != nil {
+		return r.ServiceType + "." + r.SyncResponse.Names[0].Name
+	}
+	return r.RequestType
+}
+
+// setHttpMethod sets the method and endpoint of the request from the HTTP annotation.
+// The generic @HTTP annotation declares them with the named arguments method and path,
+// and whether the request has a body with hasBody.
+func (r *ParseResult) setHttpMethod(annotation Annotation) error {
+	if annotation.Key != httpMethodGeneric {
+		for arg := range annotation.Args {
+			return fmt.Errorf("invalid @%s annotation: unknown argument %s", annotation.Key, arg)
+		}
+		r.HttpMethod = annotation.Key
+		r.ApiEndpoint = annotation.Value
+		_, r.HasBody = bodyMethods[r.HttpMethod]
+		return nil
+	}
+
+	for arg := range annotation.Args {
+		if arg != argMethod && arg != argPath && arg != argHasBody {
+			return fmt.Errorf("invalid @%s annotation: unknown argument %s", httpMethodGeneric, arg)
+		}
+	}
+	method, ok := annotation.Args[argMethod]
+	if !ok || !methodPattern.MatchString(method) {
+		return fmt.Errorf("invalid @%s annotation: %s must be an HTTP method, found %q", httpMethodGeneric, argMethod, method)
+	}
+	endpoint, ok := annotation.Args[argPath]
+	if !ok {
+		return fmt.Errorf("invalid @%s annotation: %s is required", httpMethodGeneric, argPath)
+	}
+	r.HttpMethod = strings.ToUpper(method)
+	r.ApiEndpoint = endpoint
+	_, r.HasBody = bodyMethods[r.HttpMethod]
+	if v, ok := annotation.Args[argHasBody]; ok {
+		hasBody, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid @%s annotation: %s must be true or false, found %q", httpMethodGeneric, argHasBody, v)
+		}
+		r.HasBody = hasBody
+	}
+	return nil
+}
+
+// addHeaders adds the static headers declared as "Name: value" to the result.
+func (r *ParseResult) addHeaders(values []string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("at least one header such as \"Accept: application/json\" must be declared")
+	}
+	if r.Headers == nil {
+		r.Headers = make(http.Header)
+	}
+	for _, v := range values {
+		i := strings.Index(v, ":")
+		if i < 0 || !headerNamePattern.MatchString(v[:i]) {
+			return fmt.Errorf("%q is not a header of the form \"Name: value\"", v)
+		}
+		r.Headers.Add(v[:i], strings.TrimSpace(v[i+1:]))
+	}
+	return nil
+}
+
+// validateArgs reports whether the named arguments of the request annotation are valid.
+func validateArgs(annotation Annotation) error {
+	for arg, value := range annotation.Args {
+		validate, ok := annotationArgs[annotation.Key][arg]
+		if !ok {
+			return fmt.Errorf("invalid @%s annotation: unknown argument %s", annotation.Key, arg)
+		}
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid @%s annotation: %s %s", annotation.Key, arg, err)
+		}
+	}
+	return nil
+}
+
+func validateFormat(s string) error {
+	if s != formatCSV && s != formatMulti {
+		return fmt.Errorf("must be %s or %s, found %q", formatCSV, formatMulti, s)
+	}
+	return nil
+}
+
+func validateFileName(s string) error {
+	if s == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+func validateContentType(s string) error {
+	mediaType, _, err := mime.ParseMediaType(s)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("must be a media type, found %q", s)
+	}
+	return nil
+}
+
+func validateBool(s string) error {
+	if _, err := strconv.ParseBool(s); err != nil {
+		return fmt.Errorf("must be true or false, found %q", s)
+	}
+	return nil
+}
+
+// parseStatusCodes parses a comma separated list of HTTP status codes.
+// Example: "200, 201, 204"
+func parseStatusCodes(s string) ([]int, error) {
+	var codes []int
+	for _, v := range strings.Split(s, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a status code", strings.TrimSpace(v))
+		}
+		if code < 100 || code > 599 {
+			return nil, fmt.Errorf("%d is not a valid status code", code)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// fieldTypes returns the type of every field of the list. Fields declared together share
+// their type, for example (a, b int) is int, int.
+func fieldTypes(list *ast.FieldList) []ast.Expr {
+	if list == nil {
+		return nil
+	}
+	var fieldTypes []ast.Expr
+	for _, f := range list.List {
+		fieldTypes = append(fieldTypes, f.Type)
+		for i := 1; i < len(f.Names); i++ {
+			fieldTypes = append(fieldTypes, f.Type)
+		}
+	}
+	return fieldTypes
+}
+
+// hasContextParam reports whether the first parameter of the method is a context.Context.
+// Requests executed by the method are then bound to the context.
+func hasContextParam(f *ast.Field) bool {
+	fn, ok := f.Type.(*ast.FuncType)
+	if !ok || len(fn.Params.List) == 0 {
+		return false
+	}
+	sel, ok := fn.Params.List[0].Type.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "context" && sel.Sel.Name == "Context"
+}
+
+// extractHttpAnnotationFromDoc returns the first HTTP annotation found in the doc comment.
+func extractHttpAnnotationFromDoc(doc *ast.CommentGroup) (Annotation, bool) {
+	if doc == nil {
+		return Annotation{}, false
+	}
+	for _, comment := range doc.List {
+		if annotation, valid := ExtractHttpAnnotation(comment.Text); valid {
+			return annotation, true
+		}
+	}
+	return Annotation{}, false
+}
+
 func httpAnnotationFilter(s string) bool {
 	_, ok := httpMethods[s]
 	return ok
@@ -198,6 +881,14 @@ func requestAnnotationFilter(s string) bool {
 	return ok
 }
 
+func interfaceAnnotationFilter(s string) bool {
+	_, ok := interfaceAnnotationTypes[s]
+	return ok
+}
+
+// ExtractHttpAnnotation returns the HTTP method annotation contained in s. @POST_FORM
+// is returned as @POST. The generic @HTTP annotation is returned with the key HTTP and
+// its named arguments.
 func ExtractHttpAnnotation(s string) (Annotation, bool) {
 	annotation, valid := extractAnnotation(httpAnnotationFilter, s)
 	if annotation.Key == httpMethodPostForm {
@@ -210,16 +901,58 @@ func ExtractRequestAnnotation(s string) (Annotation, bool) {
 	return extractAnnotation(requestAnnotationFilter, s)
 }
 
+func ExtractInterfaceAnnotation(s string) (Annotation, bool) {
+	return extractAnnotation(interfaceAnnotationFilter, s)
+}
+
 func extractAnnotation(filter annotationFilter, s string) (Annotation, bool) {
-	annotation := Annotation{}
-	valid := false
+	annotation, found, err := parseAnnotation(s)
+	if !found || err != nil || !filter(annotation.Key) {
+		return Annotation{}, false
+	}
+	return annotation, true
+}
+
+// parseAnnotation parses the first annotation contained in s. It reports whether s
+// contains an annotation and returns an error if its arguments are malformed.
+func parseAnnotation(s string) (Annotation, bool, error) {
 	match := re.FindStringSubmatch(s)
-	if len(match) == 3 && filter(match[1]) {
-		annotation = Annotation{
-			Key:   match[1],
-			Value: match[2],
+	if len(match) != 3 {
+		return Annotation{}, false, nil
+	}
+	annotation := Annotation{Key: match[1]}
+	args := match[2]
+	for i := 0; strings.TrimSpace(args) != ""; i++ {
+		arg := argRe.FindStringSubmatch(args)
+		if arg == nil {
+			return Annotation{}, true, fmt.Errorf("invalid @%s annotation: malformed arguments %s", annotation.Key, strings.TrimSpace(args))
+		}
+		args = args[len(arg[0]):]
+		name, value := arg[1], arg[2]
+		if arg[3] != "" {
+			value = arg[3]
 		}
-		valid = true
+		if name == "" {
+			if _, ok := multiValueAnnotations[annotation.Key]; ok {
+				// The unnamed, quoted values precede the named arguments
+				if i > len(annotation.Values) || arg[3] != "" {
+					return Annotation{}, true, fmt.Errorf("invalid @%s annotation: unnamed values must be quoted and precede the named arguments", annotation.Key)
+				}
+				annotation.Values = append(annotation.Values, value)
+				annotation.Value = annotation.Values[0]
+				continue
+			}
+			// Only the first argument may be an unnamed, quoted value
+			if i > 0 || arg[3] != "" {
+				return Annotation{}, true, fmt.Errorf("invalid @%s annotation: only the first argument may be an unnamed, quoted value", annotation.Key)
+			}
+			annotation.Value = value
+			continue
+		}
+		if annotation.Args == nil {
+			annotation.Args = make(map[string]string)
+		}
+		annotation.Args[name] = value
 	}
-	return annotation, valid
+	return annotation, true, nil
 }
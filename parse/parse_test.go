@@ -1,9 +1,14 @@
 package parse
 
 import (
+	"fmt"
 	"go/ast"
+	"go/importer"
 	"go/parser"
+	"go/scanner"
 	"go/token"
+	"go/types"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,42 +29,42 @@ func TestExtractHttpAnnotation(t *testing.T) {
 		{
 			"@DELETE(\"/test\")",
 			result{
-				Annotation{"DELETE", "/test"},
+				Annotation{Key: "DELETE", Value: "/test"},
 				true,
 			},
 		},
 		{
 			"@GET(\"/test\")",
 			result{
-				Annotation{"GET", "/test"},
+				Annotation{Key: "GET", Value: "/test"},
 				true,
 			},
 		},
 		{
 			"@HEAD(\"/test\")",
 			result{
-				Annotation{"HEAD", "/test"},
+				Annotation{Key: "HEAD", Value: "/test"},
 				true,
 			},
 		},
 		{
 			"@POST(\"/test\")",
 			result{
-				Annotation{"POST", "/test"},
+				Annotation{Key: "POST", Value: "/test"},
 				true,
 			},
 		},
 		{
 			"@POST_FORM(\"/test\")",
 			result{
-				Annotation{"POST", "/test"},
+				Annotation{Key: "POST", Value: "/test"},
 				true,
 			},
 		},
 		{
 			"@PUT(\"/test\")",
 			result{
-				Annotation{"PUT", "/test"},
+				Annotation{Key: "PUT", Value: "/test"},
 				true,
 			},
 		},
@@ -101,10 +106,45 @@ func TestExtractHttpAnnotation(t *testing.T) {
 		{
 			"@GET(\"\")",
 			result{
-				Annotation{"GET", ""},
+				Annotation{Key: "GET", Value: ""},
 				true,
 			},
 		},
+		{
+			"@PATCH(\"/test\")",
+			result{
+				Annotation{Key: "PATCH", Value: "/test"},
+				true,
+			},
+		},
+		{
+			"@OPTIONS(\"/test\")",
+			result{
+				Annotation{Key: "OPTIONS", Value: "/test"},
+				true,
+			},
+		},
+		{
+			"@HTTP(method=\"PROPFIND\", path=\"/test/{id}\", hasBody=true)",
+			result{
+				Annotation{Key: "HTTP", Args: map[string]string{"method": "PROPFIND", "path": "/test/{id}", "hasBody": "true"}},
+				true,
+			},
+		},
+		{
+			"@GET(\"/test\", \"/other\")",
+			result{
+				nilAnnotaiton,
+				false,
+			},
+		},
+		{
+			"@GET(/test)",
+			result{
+				nilAnnotaiton,
+				false,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -129,49 +169,56 @@ func TestExtractRequestAnnotation(t *testing.T) {
 		{
 			"@FIELD(\"test_1\")",
 			result{
-				Annotation{"FIELD", "test_1"},
+				Annotation{Key: "FIELD", Value: "test_1"},
 				true,
 			},
 		},
 		{
 			"@HEADER(\"test_2\")",
 			result{
-				Annotation{"HEADER", "test_2"},
+				Annotation{Key: "HEADER", Value: "test_2"},
 				true,
 			},
 		},
 		{
 			"@PART(\"test_3\")",
 			result{
-				Annotation{"PART", "test_3"},
+				Annotation{Key: "PART", Value: "test_3"},
 				true,
 			},
 		},
 		{
 			"@PATH(\"test_4\")",
 			result{
-				Annotation{"PATH", "test_4"},
+				Annotation{Key: "PATH", Value: "test_4"},
 				true,
 			},
 		},
 		{
 			"@QUERY(\"test_5\")",
 			result{
-				Annotation{"QUERY", "test_5"},
+				Annotation{Key: "QUERY", Value: "test_5"},
 				true,
 			},
 		},
 		{
 			"@SYNC(\"test_6\")",
 			result{
-				Annotation{"SYNC", "test_6"},
+				Annotation{Key: "SYNC", Value: "test_6"},
 				true,
 			},
 		},
 		{
 			"@ASYNC(\"test_7\")",
 			result{
-				Annotation{"ASYNC", "test_7"},
+				Annotation{Key: "ASYNC", Value: "test_7"},
+				true,
+			},
+		},
+		{
+			"@BODY(\"test_8\")",
+			result{
+				Annotation{Key: "BODY", Value: "test_8"},
 				true,
 			},
 		},
@@ -189,6 +236,13 @@ func TestExtractRequestAnnotation(t *testing.T) {
 				false,
 			},
 		},
+		{
+			"@QUERY(\"test_9\", encoded=true)",
+			result{
+				Annotation{Key: "QUERY", Value: "test_9", Args: map[string]string{"encoded": "true"}},
+				true,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -206,7 +260,7 @@ func TestParseInvalidCases(t *testing.T) {
 
 	var testCases = []struct {
 		input  testCase
-		output *ParseResult
+		output []*ParseResult
 	}{
 		// Empty file
 		{
@@ -216,7 +270,7 @@ func TestParseInvalidCases(t *testing.T) {
 				package main
 				`,
 			},
-			newParseResult(""),
+			nil,
 		},
 		// File containing nothing to parse
 		{
@@ -229,7 +283,7 @@ func TestParseInvalidCases(t *testing.T) {
 				}
 				`,
 			},
-			newParseResult("main"),
+			nil,
 		},
 		// File containing interface with missing http annotaiton
 		{
@@ -241,7 +295,7 @@ func TestParseInvalidCases(t *testing.T) {
 				}
 				`,
 			},
-			newParseResult("test"),
+			nil,
 		},
 		// Invalid annotation
 		{
@@ -254,7 +308,7 @@ func TestParseInvalidCases(t *testing.T) {
 				}
 				`,
 			},
-			newParseResult("test"),
+			nil,
 		},
 		// Valid http request annotation
 		{
@@ -267,62 +321,167 @@ func TestParseInvalidCases(t *testing.T) {
 				}
 				`,
 			},
-			&ParseResult{
-				PackageName:         "test",
-				PathSubstitutions:   make(map[string]*ast.Field),
-				QueryParams:         make(map[string]*ast.Field),
-				PostFormParams:      make(map[string]*ast.Field),
-				PostMultiPartParams: make(map[string]*ast.Field),
-				PostParams:          make(map[string]*ast.Field),
-				HeaderParams:        make(map[string]*ast.Field),
-				RequestType:         "GetPhotosRequestBuilder",
-				ApiEndpoint:         "/photos",
-				HttpMethod:          "GET",
-			},
-		},
-		// Invalid request annotation
-		{
-			testCase{
-				"test",
-				`
-				package test
-				// @GET("/photos")
-				type GetPhotosRequestBuilder interface {
-					// @INVALID("invalid")
-					InvalidOp(i int) GetPhotosRequestBuilder
-				}
-				`,
-			},
-			&ParseResult{
-				PackageName:         "test",
-				PathSubstitutions:   make(map[string]*ast.Field),
-				QueryParams:         make(map[string]*ast.Field),
-				PostFormParams:      make(map[string]*ast.Field),
-				PostMultiPartParams: make(map[string]*ast.Field),
-				PostParams:          make(map[string]*ast.Field),
-				HeaderParams:        make(map[string]*ast.Field),
-				RequestType:         "GetPhotosRequestBuilder",
-				ApiEndpoint:         "/photos",
-				HttpMethod:          "GET",
-			},
+			[]*ParseResult{{
+				PackageName: "test",
+				RequestType: "GetPhotosRequestBuilder",
+				ApiEndpoint: "/photos",
+				HttpMethod:  "GET",
+			}},
 		},
 	}
 
 	for _, tc := range testCases {
-		fset := token.NewFileSet()
-		f, err := parser.ParseFile(fset, "input.go", tc.input.src, parser.ParseComments)
+		result, err := parseSource(t, tc.input.src)
 		assert.NoError(t, err)
-		p := NewParser(f, "")
-		result := p.Parse()
-		assert.ObjectsAreEqualValues(tc.output, result)
+		assert.Equal(t, tc.output, result)
 	}
 }
 
+func TestParseDiagnostics(t *testing.T) {
+	src := `package test
+
+// @GET("/photos/{id}")
+type GetPhotoRequestBuilder interface {
+	// @INVALID("invalid")
+	InvalidOp(i int) GetPhotoRequestBuilder
+
+	NoDoc() GetPhotoRequestBuilder
+
+	Embedded
+
+	// @BODY("photo")
+	Photo(photo Photo) GetPhotoRequestBuilder
+}
+
+// @POST("/photos")
+// @QUERY("size")
+type PostPhotoRequestBuilder interface {
+	// @BODY("photo")
+	Photo(photo Photo) PostPhotoRequestBuilder
+
+	// @BODY("album")
+	Album(album Album) PostPhotoRequestBuilder
+
+	// @QUERY("size", format=)
+	Size(size int) PostPhotoRequestBuilder
+}
+`
+	// Every problem is reported instead of the first one
+	results, err := parseSource(t, src)
+	assert.Nil(t, results)
+	errs, ok := err.(scanner.ErrorList)
+	if !assert.True(t, ok, "%v", err) {
+		return
+	}
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		"input.go:3:1: GetPhotoRequestBuilder: path placeholder {id} has no matching @PATH annotation",
+		"input.go:5:2: GetPhotoRequestBuilder.InvalidOp: unknown annotation @INVALID",
+		"input.go:8:2: GetPhotoRequestBuilder.NoDoc: missing doc comment, every method must be annotated with a request annotation such as @QUERY or @SYNC",
+		"input.go:10:2: GetPhotoRequestBuilder: embedded interface Embedded is not supported, every method must be annotated",
+		"input.go:13:2: GetPhotoRequestBuilder.Photo: @BODY is not supported for GET requests",
+		"input.go:17:1: PostPhotoRequestBuilder: @QUERY annotates a method of the request builder, not the interface",
+		"input.go:23:2: PostPhotoRequestBuilder.Album: only one @BODY annotation may be declared per request",
+		"input.go:25:2: PostPhotoRequestBuilder.Size: invalid @QUERY annotation: malformed arguments format=",
+	}, messages)
+
+	// Without a file set the problems are reported without a position
+	f, err := parser.ParseFile(token.NewFileSet(), "input.go", src, parser.ParseComments)
+	assert.NoError(t, err)
+	_, err = NewParser(f, "test").Parse()
+	if errs, ok := err.(scanner.ErrorList); assert.True(t, ok) && assert.Len(t, errs, 8) {
+		assert.Equal(t, "GetPhotoRequestBuilder.InvalidOp: unknown annotation @INVALID", errs[0].Error())
+	}
+}
+
+func TestParsePathPlaceholders(t *testing.T) {
+	var testCases = []struct {
+		endpoint     string
+		paths        []string
+		placeholders []string
+		valid        bool
+	}{
+		{"/photos", nil, nil, true},
+		{"/photos/{id}", []string{"id"}, []string{"id"}, true},
+		{"/users/{user_id}/photos/{photo_id}", []string{"photo_id", "user_id"}, []string{"user_id", "photo_id"}, true},
+		{"/photos/{id}/similar/{id}", []string{"id"}, []string{"id"}, true},
+		// Placeholder without @PATH
+		{"/users/{id}/friends", nil, nil, false},
+		// @PATH without placeholder
+		{"/users/{id}/friends", []string{"id", "photo_id"}, nil, false},
+		// Placeholder substituted twice
+		{"/photos/{id}", []string{"id", "id"}, nil, false},
+	}
+
+	for _, tc := range testCases {
+		src := `
+			package test
+			// @GET("` + tc.endpoint + `")
+			type PhotoRequestBuilder interface {
+		`
+		for i, path := range tc.paths {
+			src += fmt.Sprintf(`
+				// @PATH("%s")
+				Path%d(value string) PhotoRequestBuilder
+			`, path, i)
+		}
+		src += "}"
+		results, err := parseSource(t, src)
+		if !tc.valid {
+			assert.Error(t, err, "%s %v", tc.endpoint, tc.paths)
+			continue
+		}
+		if assert.NoError(t, err, "%s %v", tc.endpoint, tc.paths) && assert.Len(t, results, 1) {
+			assert.Equal(t, tc.placeholders, results[0].PathPlaceholders)
+		}
+	}
+}
+
+func TestParseDeclarationOrder(t *testing.T) {
+	src := `
+		package test
+		// @POST("/photos")
+		type UploadPhotoRequestBuilder interface {
+			// @PART("thumbnail")
+			Thumbnail(data []byte) UploadPhotoRequestBuilder
+
+			// @QUERY("z")
+			Z(value string) UploadPhotoRequestBuilder
+
+			// @PART("photo")
+			Photo(data []byte) UploadPhotoRequestBuilder
+
+			// @QUERY("a")
+			A(value string) UploadPhotoRequestBuilder
+
+			// @PART("caption")
+			Caption(caption string) UploadPhotoRequestBuilder
+		}
+		`
+	results, err := parseSource(t, src)
+	if !assert.NoError(t, err) || !assert.Len(t, results, 1) {
+		return
+	}
+	names := func(fields []*ast.Field) []string {
+		var names []string
+		for _, f := range fields {
+			names = append(names, f.Names[0].Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Thumbnail", "Z", "Photo", "A", "Caption"}, names(results[0].Params))
+	assert.Equal(t, []string{"Z", "A"}, names(results[0].QueryParams))
+	assert.Equal(t, []string{"Thumbnail", "Photo", "Caption"}, names(results[0].PostMultiPartParams))
+}
+
 func TestParseValidCases(t *testing.T) {
 	// Valid Request
 	src := `
 		package test
-		// @GET("/photos/{id}")
+		// @POST("/photos/{id}")
 		type GetPhotoDetailsRequestBuilder interface {
 			// @PATH("id")
 			PhotoID(id string) GetPhotoDetailsRequestBuilder
@@ -347,16 +506,14 @@ func TestParseValidCases(t *testing.T) {
 		}
 		`
 	expectedResult := &ParseResult{
-		PackageName:         "test",
-		PathSubstitutions:   make(map[string]*ast.Field),
-		QueryParams:         make(map[string]*ast.Field),
-		PostFormParams:      make(map[string]*ast.Field),
-		PostMultiPartParams: make(map[string]*ast.Field),
-		PostParams:          make(map[string]*ast.Field),
-		HeaderParams:        make(map[string]*ast.Field),
-		RequestType:         "GetPhotoDetailsRequestBuilder",
-		ApiEndpoint:         "/photos/{id}",
-		HttpMethod:          "GET",
+		PackageName:      "test",
+		RequestType:      "GetPhotoDetailsRequestBuilder",
+		ApiEndpoint:      "/photos/{id}",
+		PathPlaceholders: []string{"id"},
+		HttpMethod:       "POST",
+		HasBody:          true,
+		ResponseType:     "GetPhotoDetailsResponse",
+		CallbackType:     "GetPhotoDetailsCallback",
 	}
 
 	fset := token.NewFileSet()
@@ -364,14 +521,1127 @@ func TestParseValidCases(t *testing.T) {
 	assert.NoError(t, err)
 
 	interfaceDecl := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.InterfaceType)
-	expectedResult.PathSubstitutions["id"] = interfaceDecl.Methods.List[0]
-	expectedResult.QueryParams["image_size"] = interfaceDecl.Methods.List[1]
-	expectedResult.PostFormParams["body"] = interfaceDecl.Methods.List[2]
-	expectedResult.HeaderParams["x-type"] = interfaceDecl.Methods.List[3]
-	expectedResult.PostMultiPartParams["data"] = interfaceDecl.Methods.List[4]
-	expectedResult.SyncResponse = interfaceDecl.Methods.List[5]
-	expectedResult.AsyncResponse = interfaceDecl.Methods.List[6]
-	p := NewParser(f, "")
-	actualResult := p.Parse()
-	assert.ObjectsAreEqualValues(expectedResult, actualResult)
+	methods := interfaceDecl.Methods.List
+	expectedResult.Params = methods[:5]
+	expectedResult.PathSubstitutions = []*ast.Field{methods[0]}
+	expectedResult.QueryParams = []*ast.Field{methods[1]}
+	expectedResult.PostFormParams = []*ast.Field{methods[2]}
+	expectedResult.HeaderParams = []*ast.Field{methods[3]}
+	expectedResult.PostMultiPartParams = []*ast.Field{methods[4]}
+	expectedResult.SyncResponse = methods[5]
+	expectedResult.AsyncResponse = methods[6]
+	p := NewParser(f, "test")
+	actualResult, err := p.Parse()
+	assert.NoError(t, err)
+	assert.Equal(t, []*ParseResult{expectedResult}, actualResult)
+}
+
+func TestParseMultipleRequests(t *testing.T) {
+	src := `
+		package test
+
+		// @GET("/photos/{id}")
+		type GetPhotoDetailsRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) GetPhotoDetailsRequestBuilder
+
+			// @SYNC("GetPhotoDetailsResponse")
+			Run() (GetPhotoDetailsResponse, error)
+		}
+
+		type GetPhotoDetailsResponse interface {
+			Title() string
+		}
+
+		type (
+			// @POST_FORM("/photos/{id}/comments")
+			PostCommentRequestBuilder interface {
+				// @PATH("id")
+				PhotoID(id string) PostCommentRequestBuilder
+
+				// @FIELD("body")
+				Body(body string) PostCommentRequestBuilder
+			}
+
+			// @DELETE("/photos/{id}")
+			DeletePhotoRequestBuilder interface {
+				// @PATH("id")
+				PhotoID(id string) DeletePhotoRequestBuilder
+			}
+		)
+		`
+
+	results, err := parseSource(t, src)
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 3) {
+		return
+	}
+
+	var testCases = []struct {
+		requestType string
+		httpMethod  string
+		apiEndpoint string
+	}{
+		{"GetPhotoDetailsRequestBuilder", "GET", "/photos/{id}"},
+		{"PostCommentRequestBuilder", "POST", "/photos/{id}/comments"},
+		{"DeletePhotoRequestBuilder", "DELETE", "/photos/{id}"},
+	}
+
+	for i, tc := range testCases {
+		assert.Equal(t, "test", results[i].PackageName)
+		assert.Equal(t, tc.requestType, results[i].RequestType)
+		assert.Equal(t, tc.httpMethod, results[i].HttpMethod)
+		assert.Equal(t, tc.apiEndpoint, results[i].ApiEndpoint)
+	}
+
+	assert.Len(t, results[0].PathSubstitutions, 1)
+	assert.Equal(t, "GetPhotoDetailsResponse", results[0].ResponseType)
+	assert.Len(t, results[1].PostFormParams, 1)
+	assert.Len(t, results[1].PathSubstitutions, 1)
+	assert.Len(t, results[2].PathSubstitutions, 1)
+	assert.Nil(t, results[2].SyncResponse)
+}
+
+func TestParseContext(t *testing.T) {
+	src := `
+		package test
+		// @GET("/photos/{id}")
+		type GetPhotoDetailsRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id string) GetPhotoDetailsRequestBuilder
+
+			// @SYNC("GetPhotoDetailsResponse")
+			Run(ctx context.Context) (GetPhotoDetailsResponse, error)
+
+			// @ASYNC("GetPhotoDetailsCallback")
+			RunAsync(ctx context.Context, callback GetPhotoDetailsCallback)
+		}
+
+		// @GET("/photos")
+		type GetPhotosRequestBuilder interface {
+			// @SYNC("GetPhotosResponse")
+			Run() (GetPhotosResponse, error)
+
+			// @ASYNC("GetPhotosCallback")
+			RunAsync(callback GetPhotosCallback)
+		}
+		`
+	results, err := parseSource(t, src)
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 2) {
+		return
+	}
+	assert.True(t, results[0].SyncContext)
+	assert.True(t, results[0].AsyncContext)
+	assert.False(t, results[1].SyncContext)
+	assert.False(t, results[1].AsyncContext)
+
+	// An asynchronous request requires a synchronous request
+	src = `
+		package test
+		// @DELETE("/photos")
+		type DeletePhotoRequestBuilder interface {
+			// @ASYNC("DeletePhotoCallback")
+			RunAsync(callback DeletePhotoCallback)
+		}
+		`
+	_, err = parseSource(t, src)
+	assert.Error(t, err)
+}
+
+func TestParseSuccessCodes(t *testing.T) {
+	var testCases = []struct {
+		annotation string
+		codes      []int
+		valid      bool
+	}{
+		{"", nil, true},
+		{`// @SUCCESS("200")`, []int{200}, true},
+		{`// @SUCCESS("200, 201,204")`, []int{200, 201, 204}, true},
+		{`// @SUCCESS("OK")`, nil, false},
+		{`// @SUCCESS("200,")`, nil, false},
+		{`// @SUCCESS("99")`, nil, false},
+	}
+
+	for _, tc := range testCases {
+		doc := `// @POST("/photos")`
+		if tc.annotation != "" {
+			doc += "\n" + tc.annotation
+		}
+		src := `
+			package test
+			` + doc + `
+			type PostPhotoRequestBuilder interface {
+			}
+			`
+		results, err := parseSource(t, src)
+		if tc.valid {
+			assert.NoError(t, err)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tc.codes, results[0].SuccessCodes)
+			}
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestParseRetry(t *testing.T) {
+	var testCases = []struct {
+		annotation string
+		attempts   int
+		valid      bool
+	}{
+		{`// @RETRY("3")`, 3, true},
+		{`// @RETRY("0")`, 0, false},
+		{`// @RETRY("always")`, 0, false},
+	}
+
+	for _, tc := range testCases {
+		src := `
+			package test
+			// @GET("/photos")
+			` + tc.annotation + `
+			type GetPhotosRequestBuilder interface {
+			}
+			`
+		results, err := parseSource(t, src)
+		if tc.valid {
+			assert.NoError(t, err)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tc.attempts, results[0].RetryAttempts)
+			}
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestParseClientName(t *testing.T) {
+	src := `
+		package test
+
+		// @GET("/invoices")
+		// @CLIENT("billing")
+		type GetInvoicesRequestBuilder interface {
+		}
+
+		// @GET("/photos")
+		type GetPhotosRequestBuilder interface {
+		}
+		`
+	results, err := parseSource(t, src)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "billing", results[0].ClientName)
+		assert.Equal(t, "", results[1].ClientName)
+	}
+}
+
+func TestParseConverterName(t *testing.T) {
+	src := `
+		package test
+
+		// @POST("/invoices")
+		// @CONVERTER("xml")
+		type CreateInvoiceRequestBuilder interface {
+		}
+
+		// @GET("/photos")
+		type GetPhotosRequestBuilder interface {
+		}
+		`
+	results, err := parseSource(t, src)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "xml", results[0].ConverterName)
+		assert.Equal(t, "", results[1].ConverterName)
+	}
+
+	src = `
+		package test
+
+		// @GET("/photos")
+		// @CONVERTER("")
+		type GetPhotosRequestBuilder interface {
+		}
+		`
+	_, err = parseSource(t, src)
+	assert.Error(t, err)
+}
+
+func TestParseService(t *testing.T) {
+	src := `
+		package test
+
+		import "context"
+
+		// @CLIENT("photos")
+		// @RETRY("3")
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(ctx context.Context,
+				// @PATH("id")
+				id string,
+				// @QUERY("size", omitempty=true)
+				size int,
+			) (Photo, error)
+
+			// @POST("/photos")
+			// @CLIENT("uploads")
+			CreatePhoto(/* @BODY("photo") */ photo Photo, /* @HEADER("X-Trace") */ trace string) (*Photo, error)
+		}
+
+		type Photo struct {
+			ID string
+		}
+		`
+	results, err := parseSource(t, src)
+	if !assert.NoError(t, err) || !assert.Len(t, results, 2) {
+		return
+	}
+
+	// Every method is a request, whose parameters are the parameters of the method
+	get := results[0]
+	assert.Equal(t, "PhotoService", get.ServiceType)
+	assert.Equal(t, "photoServiceGetPhoto", get.RequestType)
+	assert.Equal(t, "GET", get.HttpMethod)
+	assert.Equal(t, "/photos/{id}", get.ApiEndpoint)
+	assert.Equal(t, []string{"id"}, get.PathPlaceholders)
+	assert.Equal(t, "Photo", get.ResponseType)
+	assert.Equal(t, "GetPhoto", get.SyncResponse.Names[0].Name)
+	assert.True(t, get.SyncContext)
+	assert.True(t, get.DecodeResponse)
+	if assert.Len(t, get.Params, 2) {
+		assert.Equal(t, "id", get.Params[0].Names[0].Name)
+		assert.Equal(t, get.Params[0], get.PathSubstitutions[0])
+		assert.Equal(t, "size", get.Params[1].Names[0].Name)
+		assert.Equal(t, get.Params[1], get.QueryParams[0])
+	}
+
+	// The annotations of the service apply to every method unless the method overrides them
+	assert.Equal(t, "photos", get.ClientName)
+	assert.Equal(t, 3, get.RetryAttempts)
+
+	create := results[1]
+	assert.Equal(t, "photoServiceCreatePhoto", create.RequestType)
+	assert.Equal(t, "uploads", create.ClientName)
+	assert.Equal(t, 3, create.RetryAttempts)
+	assert.Equal(t, "*Photo", create.ResponseType)
+	assert.False(t, create.SyncContext)
+	assert.Len(t, create.PostParams, 1)
+	assert.Len(t, create.HeaderParams, 1)
+}
+
+func TestParseServiceDiagnostics(t *testing.T) {
+	src := `package test
+
+// @QUERY("q")
+type PhotoService interface {
+	// @GET("/photos/{id}")
+	GetPhoto(id string) (Photo, error)
+
+	Unannotated() (Photo, error)
+
+	// @GET("/photos")
+	// @SYNC("Photo")
+	ListPhotos(
+		// @QUERY("a")
+		// @QUERY("b")
+		q string,
+		// @SYNC("Photo")
+		page int,
+	) error
+
+	// @GET("/search")
+	Search(/* @QUERY("q") */ string) (Photo, error)
+}
+`
+	_, err := parseSource(t, src)
+	errs, ok := err.(scanner.ErrorList)
+	if !assert.True(t, ok, "%v", err) {
+		return
+	}
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		"input.go:3:1: PhotoService: @QUERY annotates a parameter of a service method, not the service",
+		"input.go:5:2: PhotoService.GetPhoto: path placeholder {id} has no matching @PATH annotation",
+		"input.go:6:11: PhotoService.GetPhoto.id: missing parameter annotation such as @PATH or @QUERY in the comment preceding the parameter",
+		"input.go:8:2: PhotoService.Unannotated: missing HTTP method annotation such as @GET, every method of a service sends a request",
+		"input.go:11:2: PhotoService.ListPhotos: @SYNC is not supported by services, the response is the result of the method",
+		"input.go:12:2: PhotoService.ListPhotos: a service method must return its response and an error, for example (Photo, error)",
+		"input.go:15:3: PhotoService.ListPhotos.q: only one annotation may be declared per parameter, found 2",
+		"input.go:16:3: PhotoService.ListPhotos.page: @SYNC does not annotate a parameter",
+		"input.go:21:27: PhotoService.Search: parameter string must be named to be annotated",
+	}, messages)
+}
+
+func TestParseURL(t *testing.T) {
+	src := `
+		package test
+
+		// @GET("/photos")
+		type ListPhotosRequestBuilder interface {
+			// @QUERY("page")
+			Page(page int) ListPhotosRequestBuilder
+
+			// @URL()
+			Next(next string) ListPhotosRequestBuilder
+		}
+		`
+	results, err := parseSource(t, src)
+	if assert.NoError(t, err) && assert.Len(t, results, 1) {
+		assert.Equal(t, "Next", results[0].URLParam.Names[0].Name)
+		assert.Equal(t, results[0].Params[1], results[0].URLParam)
+		assert.Len(t, results[0].QueryParams, 1)
+	}
+
+	// The URL replaces the endpoint of the request so it may only be supplied once
+	src = `package test
+
+// @GET("/albums")
+type ListAlbumsRequestBuilder interface {
+	// @URL()
+	Next(next string) ListAlbumsRequestBuilder
+
+	// @URL()
+	Previous(previous string) ListAlbumsRequestBuilder
+}
+`
+	_, err = parseSource(t, src)
+	if errs, ok := err.(scanner.ErrorList); assert.True(t, ok, "%v", err) && assert.Len(t, errs, 1) {
+		assert.Equal(t, "input.go:9:2: ListAlbumsRequestBuilder.Previous: only one @URL annotation may be declared per request", errs[0].Error())
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	src := `
+		package test
+
+		// @GET("/repos")
+		// @HEADERS("Accept: application/vnd.github.v3+json", "x-api-version:2")
+		// @HEADERS("X-Api-Version: 3")
+		type ListReposRequestBuilder interface {
+		}
+
+		// @HEADERS("User-Agent: gorest")
+		type RepoService interface {
+			// @GET("/repos/{id}")
+			// @HEADERS("Accept: application/json")
+			GetRepo(/* @PATH("id") */ id string) (Repo, error)
+
+			// @DELETE("/repos/{id}")
+			DeleteRepo(/* @PATH("id") */ id string) (Repo, error)
+		}
+		`
+	results, err := parseSource(t, src)
+	if !assert.NoError(t, err) || !assert.Len(t, results, 3) {
+		return
+	}
+
+	annotation, valid := ExtractInterfaceAnnotation(`@HEADERS("Accept: a", "X-Version: 2")`)
+	assert.True(t, valid)
+	assert.Equal(t, Annotation{Key: "HEADERS", Value: "Accept: a", Values: []string{"Accept: a", "X-Version: 2"}}, annotation)
+
+	// Header names are canonical and the values of every annotation are added
+	assert.Equal(t, http.Header{
+		"Accept":        {"application/vnd.github.v3+json"},
+		"X-Api-Version": {"2", "3"},
+	}, results[0].Headers)
+
+	// The headers of a service method are added to those of the service
+	assert.Equal(t, http.Header{
+		"User-Agent": {"gorest"},
+		"Accept":     {"application/json"},
+	}, results[1].Headers)
+	assert.Equal(t, http.Header{"User-Agent": {"gorest"}}, results[2].Headers)
+
+	for _, annotation := range []string{
+		`@HEADERS()`,
+		`@HEADERS("Accept")`,
+		`@HEADERS("Bad Name: value")`,
+		`@HEADERS("Accept: a", Accept)`,
+		`@HEADERS(name="x", "Accept: a")`,
+	} {
+		src := `
+			package test
+			// @GET("/repos")
+			// ` + annotation + `
+			type ListReposRequestBuilder interface {
+			}
+		`
+		_, err := parseSource(t, src)
+		assert.Error(t, err, annotation)
+	}
+}
+
+func TestParseHttpMethods(t *testing.T) {
+	var testCases = []struct {
+		annotation string
+		method     string
+		endpoint   string
+		hasBody    bool
+	}{
+		{`@GET("/photos")`, "GET", "/photos", false},
+		{`@HEAD("/photos")`, "HEAD", "/photos", false},
+		{`@OPTIONS("/photos")`, "OPTIONS", "/photos", false},
+		{`@DELETE("/photos")`, "DELETE", "/photos", false},
+		{`@POST("/photos")`, "POST", "/photos", true},
+		{`@POST_FORM("/photos")`, "POST", "/photos", true},
+		{`@PUT("/photos")`, "PUT", "/photos", true},
+		{`@PATCH("/photos")`, "PATCH", "/photos", true},
+		{`@HTTP(method="PROPFIND", path="/files", hasBody=true)`, "PROPFIND", "/files", true},
+		{`@HTTP(method="MKCOL", path="/files")`, "MKCOL", "/files", false},
+		{`@HTTP(path="/photos", method="post")`, "POST", "/photos", true},
+		{`@HTTP(method="DELETE", path="/photos", hasBody=true)`, "DELETE", "/photos", true},
+	}
+
+	for _, tc := range testCases {
+		src := `
+			package test
+			// ` + tc.annotation + `
+			type PhotoRequestBuilder interface {
+				// @SYNC("PhotoResponse")
+				Run() (PhotoResponse, error)
+			}
+		`
+		results, err := parseSource(t, src)
+		if assert.NoError(t, err, tc.annotation) && assert.Len(t, results, 1) {
+			assert.Equal(t, tc.method, results[0].HttpMethod, tc.annotation)
+			assert.Equal(t, tc.endpoint, results[0].ApiEndpoint, tc.annotation)
+			assert.Equal(t, tc.hasBody, results[0].HasBody, tc.annotation)
+		}
+	}
+}
+
+func TestParseInvalidHttpMethods(t *testing.T) {
+	var testCases = []string{
+		`@HTTP(path="/files")`,
+		`@HTTP(method="PROP FIND", path="/files")`,
+		`@HTTP(method="PROPFIND")`,
+		`@HTTP(method="PROPFIND", path="/files", hasBody=maybe)`,
+		`@HTTP(method="PROPFIND", path="/files", body=true)`,
+		`@GET("/files", hasBody=true)`,
+	}
+
+	for _, annotation := range testCases {
+		src := `
+			package test
+			// ` + annotation + `
+			type FilesRequestBuilder interface {
+				// @SYNC("FilesResponse")
+				Run() (FilesResponse, error)
+			}
+		`
+		_, err := parseSource(t, src)
+		assert.Error(t, err, annotation)
+	}
+}
+
+func TestParseAnnotationArgs(t *testing.T) {
+	var testCases = []struct {
+		annotation string
+		valid      bool
+	}{
+		{`@QUERY("tags")`, true},
+		{`@QUERY("tags", format="csv")`, true},
+		{`@QUERY("tags", format="multi", omitempty=true)`, true},
+		{`@FIELD("tags", format="csv", omitempty=false)`, true},
+		{`@QUERY("tags", format="tsv")`, false},
+		{`@QUERY("tags", omitempty=maybe)`, false},
+		{`@QUERY("tags", explode=true)`, false},
+		{`@PATH("tags", format="csv")`, false},
+		{`@QUERY("tags", encoded=true)`, true},
+		{`@QUERY("tags", encoded=yes)`, false},
+		{`@PART("tags", filename="tags.txt", contentType="text/plain; charset=utf-8")`, true},
+		{`@PART("tags", filename="")`, false},
+		{`@PART("tags", contentType="text")`, false},
+		{`@QUERY("tags", filename="tags.txt")`, false},
+		{`@URL()`, true},
+		{`@URL("https://api.example.com")`, false},
+		{`@URL(encoded=true)`, false},
+		{`@QUERY("tags", "ids")`, false},
+	}
+
+	for _, tc := range testCases {
+		src := `
+			package test
+			// @POST("/photos")
+			type PhotosRequestBuilder interface {
+				// ` + tc.annotation + `
+				Tags(tags []string) PhotosRequestBuilder
+			}
+		`
+		_, err := parseSource(t, src)
+		if tc.valid {
+			assert.NoError(t, err, tc.annotation)
+		} else {
+			assert.Error(t, err, tc.annotation)
+		}
+	}
+}
+
+func TestParseMethodSignatures(t *testing.T) {
+	var testCases = []struct {
+		typeParams string
+		annotation string
+		method     string
+		message    string
+	}{
+		// A setter without a value
+		{"", `@QUERY("q")`, "Q() PhotoRequestBuilder", "input.go:6:2: PhotoRequestBuilder.Q: the method must accept the value of the @QUERY parameter, for example Q(value string)"},
+		// A setter with more than one value
+		{"", `@URL()`, "Next(x, y string) PhotoRequestBuilder", "input.go:6:2: PhotoRequestBuilder.Next: the method must accept only the value of the @URL parameter, found 2 parameters"},
+		// A setter whose value is blank
+		{"", `@QUERY("q")`, "Q(_ int) PhotoRequestBuilder", "input.go:6:4: PhotoRequestBuilder.Q: the value of the @QUERY parameter must be named, found _"},
+		// A header name which is not a token
+		{"", `@HEADER("Bad Header")`, "Header(v string) PhotoRequestBuilder", `input.go:6:2: PhotoRequestBuilder.Header: invalid @HEADER annotation: "Bad Header" is not a header name`},
+		// A response without a type
+		{"", `@SYNC("")`, "Run() (Photo, error)", "input.go:6:2: PhotoRequestBuilder.Run: invalid @SYNC annotation: the response type must not be empty"},
+		// A response which is not returned
+		{"", `@SYNC("Photo")`, "Run()", "input.go:6:2: PhotoRequestBuilder.Run: a @SYNC method must return its response and an error, (Photo, error)"},
+		{"", `@SYNC("Photo")`, "Run(page int) (Photo, error)", "input.go:6:2: PhotoRequestBuilder.Run: a @SYNC method accepts no parameter other than a context.Context"},
+		// A callback which is not accepted
+		{"", `@ASYNC("PhotoCallback")`, "RunAsync()", "input.go:6:2: PhotoRequestBuilder.RunAsync: an @ASYNC method must accept the callback and return nothing, for example RunAsync(callback PhotoCallback)"},
+		// A request builder with type parameters
+		{"[T any]", `@QUERY("q")`, "Q(q T) PhotoRequestBuilder[T]", "input.go:4:6: PhotoRequestBuilder: type parameters are not supported, the types of a request must be known to generate it"},
+	}
+
+	for _, tc := range testCases {
+		src := "package test\n\n// @POST(\"/photos\")\ntype PhotoRequestBuilder" + tc.typeParams + " interface {\n\t// " + tc.annotation + "\n\t" + tc.method + "\n}\n"
+		_, err := parseSource(t, src)
+		if errs, ok := err.(scanner.ErrorList); assert.True(t, ok, "%s %v", tc.method, err) {
+			assert.Equal(t, tc.message, errs[0].Error())
+		}
+	}
+}
+
+func TestParseBody(t *testing.T) {
+	var testCases = []struct {
+		src   string
+		valid bool
+	}{
+		// Body on a POST request
+		{
+			`
+			package test
+			// @POST("/photos")
+			type PostPhotoRequestBuilder interface {
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) PostPhotoRequestBuilder
+			}
+			`,
+			true,
+		},
+		// Body on a PUT request
+		{
+			`
+			package test
+			// @PUT("/photos")
+			type PutPhotoRequestBuilder interface {
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) PutPhotoRequestBuilder
+			}
+			`,
+			true,
+		},
+		// Body on a GET request
+		{
+			`
+			package test
+			// @GET("/photos")
+			type GetPhotosRequestBuilder interface {
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) GetPhotosRequestBuilder
+			}
+			`,
+			false,
+		},
+		// Body on a PATCH request
+		{
+			`
+			package test
+			// @PATCH("/photos")
+			type PatchPhotoRequestBuilder interface {
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) PatchPhotoRequestBuilder
+			}
+			`,
+			true,
+		},
+		// Body on a generic request declaring a body
+		{
+			`
+			package test
+			// @HTTP(method="PROPFIND", path="/files", hasBody=true)
+			type PropfindRequestBuilder interface {
+				// @BODY("query")
+				Query(query PropQuery) PropfindRequestBuilder
+			}
+			`,
+			true,
+		},
+		// Body on an OPTIONS request
+		{
+			`
+			package test
+			// @OPTIONS("/photos")
+			type OptionsPhotosRequestBuilder interface {
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) OptionsPhotosRequestBuilder
+			}
+			`,
+			false,
+		},
+		// More than one body
+		{
+			`
+			package test
+			// @POST("/photos")
+			type PostPhotoRequestBuilder interface {
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) PostPhotoRequestBuilder
+
+				// @BODY("album")
+				AlbumMetadata(metadata Metadata) PostPhotoRequestBuilder
+			}
+			`,
+			false,
+		},
+		// Form field on a GET request
+		{
+			`
+			package test
+			// @GET("/photos")
+			type GetPhotosRequestBuilder interface {
+				// @FIELD("title")
+				Title(title string) GetPhotosRequestBuilder
+			}
+			`,
+			false,
+		},
+		// Multipart part on a DELETE request
+		{
+			`
+			package test
+			// @DELETE("/photos")
+			type DeletePhotosRequestBuilder interface {
+				// @PART("photo")
+				Photo(data []byte) DeletePhotosRequestBuilder
+			}
+			`,
+			false,
+		},
+		// Body followed by a form field
+		{
+			`
+			package test
+			// @POST("/photos")
+			type PostPhotoRequestBuilder interface {
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) PostPhotoRequestBuilder
+
+				// @FIELD("title")
+				Title(title string) PostPhotoRequestBuilder
+			}
+			`,
+			false,
+		},
+		// Multipart part followed by a body
+		{
+			`
+			package test
+			// @PUT("/photos")
+			type PutPhotoRequestBuilder interface {
+				// @PART("photo")
+				Photo(data []byte) PutPhotoRequestBuilder
+
+				// @BODY("photo")
+				PhotoMetadata(metadata Metadata) PutPhotoRequestBuilder
+			}
+			`,
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		results, err := parseSource(t, tc.src)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Len(t, results, 1)
+			assert.Len(t, results[0].PostParams, 1)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+// parseSource parses the source of a file and the requests it declares. Problems are
+// reported with their position in the file.
+func parseSource(t *testing.T, src string) ([]*ParseResult, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "input.go", src, parser.ParseComments)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return NewParserWithFileSet(fset, f, f.Name.Name).Parse()
+}
+
+// parsePackageFiles type checks the sources as a single package and parses the first source.
+func parsePackageFiles(t *testing.T, srcs ...string) ([]*ParseResult, error) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, src := range srcs {
+		f, err := parser.ParseFile(fset, "input.go", src, parser.ParseComments)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		files = append(files, f)
+	}
+
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(err error) {},
+	}
+	pkg, _ := conf.Check("test", fset, files, info)
+	return NewPackageParser(fset, files[0], pkg, info).Parse()
+}
+
+func TestParsePackage(t *testing.T) {
+	request := `
+		package test
+		// @POST("/photos")
+		type PostPhotoRequestBuilder interface {
+			// @BODY("photo")
+			PhotoMetadata(metadata Metadata) PostPhotoRequestBuilder
+
+			// @SYNC("PhotoResponse")
+			Run() (PhotoResponse, error)
+
+			// @ASYNC("PhotoCallback")
+			RunAsync(callback PhotoCallback)
+		}
+		`
+	declarations := `
+		package test
+		type Metadata struct {
+			Title string
+		}
+
+		type PhotoResponse interface {
+			Title() string
+		}
+		`
+
+	var testCases = []struct {
+		constructor string
+		valid       bool
+	}{
+		// Constructor accepting an io.Reader
+		{
+			`
+			package test
+			import "io"
+			func NewPhotoResponse(r io.Reader) (PhotoResponse, error) {
+				return nil, nil
+			}
+			`,
+			true,
+		},
+		// Missing constructor
+		{
+			`
+			package test
+			`,
+			false,
+		},
+		// Constructor returning a different type
+		{
+			`
+			package test
+			import "io"
+			func NewPhotoResponse(r io.Reader) (*Metadata, error) {
+				return nil, nil
+			}
+			`,
+			false,
+		},
+		// Constructor not accepting an io.Reader
+		{
+			`
+			package test
+			func NewPhotoResponse(data []byte) (PhotoResponse, error) {
+				return nil, nil
+			}
+			`,
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		results, err := parsePackageFiles(t, request, declarations, tc.constructor)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Len(t, results, 1)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestParsePackageResponseDecoding(t *testing.T) {
+	var testCases = []struct {
+		response     string
+		declarations string
+		decode       bool
+		valid        bool
+	}{
+		{"Photo", "type Photo struct{ ID int }", true, true},
+		{"*Photo", "type Photo struct{ ID int }", true, true},
+		// A constructor takes precedence
+		{"Photo", "type Photo struct{ ID int }\nfunc NewPhoto(r io.Reader) (Photo, error) { return Photo{}, nil }", false, true},
+		{"*Photo", "type Photo struct{ ID int }\nfunc NewPhoto(r io.Reader) (*Photo, error) { return nil, nil }", false, true},
+		// The constructor of a pointer response returns the pointer
+		{"*Photo", "type Photo struct{ ID int }\nfunc NewPhoto(r io.Reader) (Photo, error) { return Photo{}, nil }", false, false},
+		// Other responses require a constructor
+		{"Photo", "type Photo []int", false, false},
+		{"Photo", "type Photo []int\nfunc NewPhoto(r io.Reader) (Photo, error) { return nil, nil }", false, true},
+	}
+
+	for _, tc := range testCases {
+		request := `
+			package test
+			// @GET("/photos")
+			type GetPhotoRequestBuilder interface {
+				// @SYNC("` + tc.response + `")
+				Run() (` + tc.response + `, error)
+			}
+			`
+		declarations := "package test\nimport \"io\"\nvar _ io.Reader\n" + tc.declarations
+		results, err := parsePackageFiles(t, request, declarations)
+		if !tc.valid {
+			assert.Error(t, err, "%s %s", tc.response, tc.declarations)
+			continue
+		}
+		if assert.NoError(t, err, "%s %s", tc.response, tc.declarations) && assert.Len(t, results, 1) {
+			assert.Equal(t, tc.decode, results[0].DecodeResponse, "%s %s", tc.response, tc.declarations)
+		}
+	}
+}
+
+func TestParsePackageQualifiedResponse(t *testing.T) {
+	var testCases = []struct {
+		response    string
+		constructor string
+		decode      bool
+		valid       bool
+	}{
+		// The constructor is declared in the package of the response
+		{"*gzip.Reader", "gzip.NewReader", false, true},
+		{"*url.URL", "url.NewURL", true, true},
+		{"url.URL", "url.NewURL", true, true},
+		// bytes.NewBuffer does not read the response
+		{"*bytes.Buffer", "bytes.NewBuffer", false, false},
+	}
+
+	for _, tc := range testCases {
+		request := `
+			package test
+
+			import (
+				"bytes"
+				"compress/gzip"
+				"net/url"
+			)
+
+			var _ bytes.Buffer
+			var _ gzip.Reader
+			var _ url.URL
+
+			// @GET("/photos")
+			type GetPhotoRequestBuilder interface {
+				// @SYNC("` + tc.response + `")
+				Run() (` + tc.response + `, error)
+			}
+			`
+		results, err := parsePackageFiles(t, request)
+		if !tc.valid {
+			if assert.Error(t, err, tc.response) {
+				assert.Contains(t, err.Error(), "constructor NewBuffer has signature", tc.response)
+			}
+			continue
+		}
+		if assert.NoError(t, err, tc.response) && assert.Len(t, results, 1) {
+			assert.Equal(t, tc.constructor, results[0].ResponseConstructor(), tc.response)
+			assert.Equal(t, tc.decode, results[0].DecodeResponse, tc.response)
+		}
+	}
+}
+
+func TestParsePackageInvalidTypes(t *testing.T) {
+	var testCases = []string{
+		// Undefined parameter type
+		`
+		package test
+		// @GET("/photos/{id}")
+		type GetPhotoRequestBuilder interface {
+			// @PATH("id")
+			PhotoID(id PhotoID) GetPhotoRequestBuilder
+		}
+		`,
+		// Body which can not be serialized
+		`
+		package test
+		// @POST("/photos")
+		type PostPhotoRequestBuilder interface {
+			// @BODY("photo")
+			Photo(photo chan int) PostPhotoRequestBuilder
+		}
+		`,
+		// Undefined parameter type of a service method
+		`
+		package test
+		type Photo struct{}
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(/* @PATH("id") */ id PhotoID) (Photo, error)
+		}
+		`,
+		// Parameter of a service method which can not be formatted
+		`
+		package test
+		type Photo struct{}
+		type Filter struct{ Name string }
+		type PhotoService interface {
+			// @GET("/photos")
+			ListPhotos(/* @QUERY("filter") */ filter Filter) (Photo, error)
+		}
+		`,
+		// Undefined response type of a service method
+		`
+		package test
+		type PhotoService interface {
+			// @GET("/photos")
+			ListPhotos() (Photos, error)
+		}
+		`,
+	}
+
+	for _, tc := range testCases {
+		_, err := parsePackageFiles(t, tc)
+		assert.Error(t, err)
+	}
+}
+
+func TestParsePackageParamTypes(t *testing.T) {
+	var testCases = []struct {
+		annotation string
+		paramType  string
+		valid      bool
+	}{
+		{`@QUERY("q")`, "string", true},
+		{`@QUERY("q")`, "*int", true},
+		{`@QUERY("q")`, "[]float64", true},
+		{`@QUERY("q")`, "time.Time", true},
+		{`@QUERY("q")`, "Size", true},
+		{`@QUERY("q")`, "[]*Size", true},
+		{`@QUERY("q")`, "interface{}", true},
+		{`@PART("q")`, "io.Reader", true},
+		{`@PART("q")`, "[]*os.File", true},
+		{`@PART("q")`, "[]byte", true},
+		{`@QUERY("q")`, "int, w ...Size", true},
+		{`@QUERY("q")`, "map[string]string", false},
+		{`@QUERY("q")`, "Filter", false},
+		{`@HEADER("X-Q")`, "*Filter", false},
+		{`@FIELD("q")`, "func() string", false},
+		{`@HEADER("X-Q")`, "chan int", false},
+		{`@QUERY("q")`, "complex128", false},
+		{`@PART("q")`, "Filter", false},
+		{`@QUERY("q")`, "string, w Filter", false},
+	}
+
+	for _, tc := range testCases {
+		src := `
+			package test
+			import (
+				"io"
+				"os"
+				"time"
+			)
+			var _ io.Reader
+			var _ *os.File
+			var _ time.Time
+			type Size int
+			func (s Size) String() string { return "" }
+			type Filter struct{ Name string }
+			// @POST("/photos")
+			type PostPhotoRequestBuilder interface {
+				// ` + tc.annotation + `
+				Param(v ` + tc.paramType + `) PostPhotoRequestBuilder
+			}
+			`
+		_, err := parsePackageFiles(t, src)
+		if tc.valid {
+			assert.NoError(t, err, "%s %s", tc.annotation, tc.paramType)
+		} else {
+			assert.Error(t, err, "%s %s", tc.annotation, tc.paramType)
+		}
+	}
+}
+
+func TestParsePackageService(t *testing.T) {
+	service := `
+		package test
+		import "context"
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(ctx context.Context, /* @PATH("id") */ id PhotoID) (*Photo, error)
+
+			// @GET("/photos")
+			ListPhotos() ([]Photo, error)
+
+			// @GET("/albums")
+			ListAlbums() (*map[string][]*Photo, error)
+		}
+		`
+	declarations := `
+		package test
+		type PhotoID string
+
+		type Photo struct {
+			ID PhotoID
+		}
+		`
+	results, err := parsePackageFiles(t, service, declarations)
+	if assert.NoError(t, err) && assert.Len(t, results, 3) {
+		assert.Equal(t, "PhotoService", results[0].ServiceType)
+		assert.True(t, results[0].DecodeResponse)
+		assert.Len(t, results[0].PathSubstitutions, 1)
+
+		// Slices and maps have no constructor and are decoded
+		assert.True(t, results[1].DecodeResponse)
+		assert.True(t, results[2].DecodeResponse)
+	}
+
+	// Problems are reported with the method of the service
+	service = `
+		package test
+		type PhotoService interface {
+			// @GET("/photos/{id}")
+			GetPhoto(/* @PATH("id") */ id PhotoID) (Photos, error)
+		}
+		type Photos interface{}
+		`
+	_, err = parsePackageFiles(t, service, declarations)
+	if errs, ok := err.(scanner.ErrorList); assert.True(t, ok, "%v", err) && assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "PhotoService.GetPhoto: response Photos is not a struct")
+	}
 }
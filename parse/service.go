@@ -0,0 +1,169 @@
+package parse
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"unicode"
+	"unicode/utf8"
+)
+
+// isService reports whether the interface is a service, which is an interface whose
+// methods are annotated with an HTTP method instead of the interface itself.
+func isService(ifc *ast.InterfaceType) bool {
+	for _, f := range ifc.Methods.List {
+		if _, valid := extractHttpAnnotationFromDoc(f.Doc); valid {
+			return true
+		}
+	}
+	return false
+}
+
+// parseService builds a ParseResult per method of a service interface, in the order the
+// methods are declared. The annotations of the service apply to every method and may be
+// overridden by the annotations of the method, except for @HEADERS whose headers are
+// added to those of the service.
+func (p *Parser) parseService(name string, doc *ast.CommentGroup, ifc *ast.InterfaceType) []*ParseResult {
+	defaults := p.newResult(name)
+	p.interfaceAnnotations(defaults, name, p.serviceAnnotations(name, doc, "a parameter of a service method, not the service"))
+
+	var results []*ParseResult
+	for _, f := range ifc.Methods.List {
+		if len(f.Names) == 0 {
+			p.errorf(f.Pos(), "%s: embedded interface %s is not supported, every method must be annotated", name, types.ExprString(f.Type))
+			continue
+		}
+		method := f.Names[0].Name
+		owner := name + "." + method
+
+		// The request types of a service are not part of its API
+		result := p.newResult(lowerFirst(name) + method)
+		result.ServiceType = name
+		result.ClientName = defaults.ClientName
+		result.ConverterName = defaults.ConverterName
+		result.RetryAttempts = defaults.RetryAttempts
+		result.SuccessCodes = defaults.SuccessCodes
+		result.Headers = defaults.Headers.Clone()
+
+		httpPos := p.interfaceAnnotations(result, owner, p.serviceAnnotations(owner, f.Doc, "a parameter of the method, not the method"))
+		if !httpPos.IsValid() {
+			p.errorf(f.Pos(), "%s: missing HTTP method annotation such as @GET, every method of a service sends a request", owner)
+		}
+		p.parseServiceMethod(result, owner, f, httpPos)
+		results = append(results, result)
+	}
+	return results
+}
+
+// serviceAnnotations returns the annotations of a service or one of its methods. Request
+// annotations are reported as they annotate the parameters of the methods.
+func (p *Parser) serviceAnnotations(owner string, doc *ast.CommentGroup, target string) []positionedAnnotation {
+	var annotations []positionedAnnotation
+	for _, a := range p.annotations(owner, doc) {
+		if a.Key == sync || a.Key == async {
+			p.errorf(a.pos, "%s: @%s is not supported by services, the response is the result of the method", owner, a.Key)
+			continue
+		}
+		if requestAnnotationFilter(a.Key) {
+			p.errorf(a.pos, "%s: @%s annotates %s", owner, a.Key, target)
+			continue
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations
+}
+
+// parseServiceMethod sets the response and the parameters of the request sent by the
+// service method f. The method returns the response and an error and may accept a
+// context.Context as its first parameter, every other parameter must be annotated.
+func (p *Parser) parseServiceMethod(result *ParseResult, owner string, f *ast.Field, httpPos token.Pos) {
+	fn := f.Type.(*ast.FuncType)
+	result.SyncResponse = f
+	result.SyncContext = hasContextParam(f)
+
+	resultTypes := fieldTypes(fn.Results)
+	if len(resultTypes) != 2 || types.ExprString(resultTypes[1]) != "error" {
+		p.errorf(f.Pos(), "%s: a service method must return its response and an error, for example (Photo, error)", owner)
+	} else {
+		result.ResponseType = types.ExprString(resultTypes[0])
+	}
+
+	pathValues := make(map[string]string)
+	for i, param := range fn.Params.List {
+		if i == 0 && result.SyncContext && len(param.Names) <= 1 {
+			continue
+		}
+		if len(param.Names) == 0 {
+			p.errorf(param.Pos(), "%s: parameter %s must be named to be annotated", owner, types.ExprString(param.Type))
+			continue
+		}
+
+		// The parameter is added to the request like the method of a request builder
+		// accepting it, so both styles share the implementation of their parameters
+		setter := &ast.Field{
+			Doc:   p.paramDoc(fn, i),
+			Names: param.Names,
+			Type:  &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{param}}},
+		}
+		annotation, ok := p.paramAnnotation(owner, setter)
+		if !ok {
+			continue
+		}
+		if err := validateArgs(annotation); err != nil {
+			p.errorf(param.Pos(), "%s.%s: %s", owner, param.Names[0].Name, err)
+		}
+		p.addParam(result, owner, setter, annotation, pathValues)
+	}
+	p.validatePath(result, owner, httpPos, pathValues)
+}
+
+// paramDoc returns the comment preceding the i-th parameter of the function, which holds
+// the annotation of a service method parameter. Comments are not attached to parameters
+// by the Go parser so the comment is found by its position in the file.
+func (p *Parser) paramDoc(fn *ast.FuncType, i int) *ast.CommentGroup {
+	start := fn.Params.Opening
+	if i > 0 {
+		start = fn.Params.List[i-1].End()
+	}
+	end := fn.Params.List[i].Pos()
+
+	var doc *ast.CommentGroup
+	for _, c := range p.file.Comments {
+		if c.Pos() > start && c.End() <= end {
+			doc = c
+		}
+	}
+	return doc
+}
+
+// paramAnnotation returns the request annotation of a service method parameter.
+// Parameters without exactly one request annotation are reported.
+func (p *Parser) paramAnnotation(owner string, f *ast.Field) (Annotation, bool) {
+	param := f.Names[0].Name
+	var annotations []Annotation
+	reported := len(p.errs)
+	for _, a := range p.annotations(owner+"."+param, f.Doc) {
+		if !requestAnnotationFilter(a.Key) || a.Key == sync || a.Key == async {
+			p.errorf(a.pos, "%s.%s: @%s does not annotate a parameter", owner, param, a.Key)
+			continue
+		}
+		annotations = append(annotations, a.Annotation)
+	}
+	switch len(annotations) {
+	case 0:
+		if len(p.errs) == reported {
+			p.errorf(f.Pos(), "%s.%s: missing parameter annotation such as @PATH or @QUERY in the comment preceding the parameter", owner, param)
+		}
+		return Annotation{}, false
+	case 1:
+		return annotations[0], true
+	}
+	p.errorf(f.Pos(), "%s.%s: only one annotation may be declared per parameter, found %d", owner, param, len(annotations))
+	return Annotation{}, false
+}
+
+// lowerFirst returns s with its first letter in lower case.
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
@@ -1,9 +1,13 @@
 package restclient
 
 import (
+	"context"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httputil"
+	"time"
 )
 
 const (
@@ -14,10 +18,106 @@ const (
 // Client provides the RequestBuilder with a configured http.Client object. In addition to a
 // http.Client, RequestBuilder can also utilize relative API URLs when the base URL is present.
 // Debugging requests and responses can be made possible by enabling the Debug mode to true.
+// Every request is passed through the ordered chain of Interceptors before it is sent.
+// Failed requests are retried according to the RetryPolicy, a nil policy disables retries.
+// Request and response bodies are converted with the Converter, a nil converter uses
+// JSONConverter.
 type Client interface {
 	BaseURL() string
 	Debug() bool
 	HttpClient() *http.Client
+	Interceptors() []Interceptor
+	RetryPolicy() *RetryPolicy
+	Converter() Converter
+}
+
+// Do sends the request with the client's http.Client after passing it through the
+// client's interceptors. The response is passed through the interceptors in reverse
+// order before it is returned. Failed requests are retried with the client's RetryPolicy.
+func Do(client Client, request *http.Request) (*http.Response, error) {
+	return DoWithRetry(client, request, client.RetryPolicy())
+}
+
+// DoWithRetry sends the request like Do but retries failed requests with the policy
+// instead of the client's RetryPolicy.
+// The interceptors are applied to every attempt. A request with a body can only be
+// retried if its GetBody func is set, which http.NewRequest does for in-memory bodies.
+func DoWithRetry(client Client, request *http.Request, policy *RetryPolicy) (*http.Response, error) {
+	if policy == nil || policy.MaxAttempts < 2 || !policy.retryMethod(request) {
+		response, err, _ := send(client, request)
+		return response, err
+	}
+
+	// Interceptors modify the request so every attempt starts from a copy of the original
+	ctx := request.Context()
+	original := request.Clone(ctx)
+	for attempt := 1; ; attempt++ {
+		response, err, retryable := send(client, request)
+		if !retryable || attempt >= policy.MaxAttempts {
+			return response, err
+		}
+
+		delay := policy.backoff(attempt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+		} else {
+			if !policy.retryStatus(response.StatusCode) {
+				return response, nil
+			}
+			if after, ok := retryAfter(response, time.Now()); ok {
+				if policy.MaxBackoff > 0 && after > policy.MaxBackoff {
+					return response, nil
+				}
+				delay = after
+			}
+		}
+
+		next, ok := rewind(original)
+		if !ok {
+			return response, err
+		}
+		if response != nil {
+			// Drain the body so the connection can be reused
+			io.Copy(ioutil.Discard, io.LimitReader(response.Body, 4096))
+			response.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		request = next
+	}
+}
+
+// rewind returns a copy of the request with a fresh body. The request can not be
+// rewound when its body can not be recreated.
+func rewind(request *http.Request) (*http.Request, bool) {
+	next := request.Clone(request.Context())
+	if request.Body == nil || request.Body == http.NoBody {
+		return next, true
+	}
+	if request.GetBody == nil {
+		return nil, false
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next.Body = body
+	return next, true
+}
+
+// sleep waits for the delay or until the context is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 func DebugRequest(request *http.Request) {
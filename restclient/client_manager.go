@@ -1,19 +1,57 @@
 package restclient
 
+import "sync"
+
+// ClientManager is a registry of named clients. It is safe for concurrent use.
 type ClientManager struct {
-	client Client
+	mu      sync.RWMutex
+	clients map[string]Client
 }
 
 var clientManager *ClientManager
 
 func init() {
-	clientManager = &ClientManager{}
+	clientManager = NewClientManager()
+}
+
+func NewClientManager() *ClientManager {
+	return &ClientManager{
+		clients: make(map[string]Client),
+	}
 }
 
+// Register registers the client with the name, replacing any client previously
+// registered with the same name. The empty name is the default client.
+func (m *ClientManager) Register(name string, client Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients[name] = client
+}
+
+// Get returns the client registered with the name or nil if there is none.
+func (m *ClientManager) Get(name string) Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clients[name]
+}
+
+// RegisterClient registers the default client which is used by requests that do not
+// declare a client name.
 func RegisterClient(client Client) {
-	clientManager.client = client
+	clientManager.Register("", client)
+}
+
+// RegisterNamedClient registers the client used by requests annotated with @CLIENT(name).
+func RegisterNamedClient(name string, client Client) {
+	clientManager.Register(name, client)
 }
 
+// GetClient returns the default client.
 func GetClient() Client {
-	return clientManager.client
+	return clientManager.Get("")
+}
+
+// GetNamedClient returns the client registered with the name.
+func GetNamedClient(name string) Client {
+	return clientManager.Get(name)
 }
@@ -0,0 +1,36 @@
+package restclient
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientManager(t *testing.T) {
+	m := NewClientManager()
+	assert.Nil(t, m.Get(""))
+
+	defaultClient := NewDefaultClient("https://api.example.com", false, http.DefaultClient)
+	billingClient := NewDefaultClient("https://billing.example.com", false, http.DefaultClient)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			m.Register("", defaultClient)
+			m.Register("billing", billingClient)
+		}()
+		go func() {
+			defer wg.Done()
+			m.Get("billing")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, defaultClient, m.Get(""))
+	assert.Equal(t, billingClient, m.Get("billing"))
+	assert.Nil(t, m.Get("search"))
+}
@@ -0,0 +1,320 @@
+package restclient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Converter encodes request bodies and decodes response bodies of a single media type.
+// Formats which are not shipped with restclient, such as protobuf or msgpack, are
+// supported by registering a Converter for them, usually created with NewConverter.
+type Converter interface {
+	// ContentType is the media type of the encoded bodies. It is sent as the
+	// Content-Type of request bodies and as the Accept header of requests.
+	ContentType() string
+
+	// Marshal encodes the value in to a request body.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes a response body in to the value v points to.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type converter struct {
+	contentType string
+	marshal     func(v interface{}) ([]byte, error)
+	unmarshal   func(data []byte, v interface{}) error
+}
+
+// NewConverter creates a Converter of the media type from a pair of marshal and
+// unmarshal funcs, for example:
+//
+//	restclient.NewConverter("application/x-protobuf",
+//		func(v interface{}) ([]byte, error) { return proto.Marshal(v.(proto.Message)) },
+//		func(data []byte, v interface{}) error { return proto.Unmarshal(data, v.(proto.Message)) })
+func NewConverter(contentType string, marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) Converter {
+	return &converter{
+		contentType: contentType,
+		marshal:     marshal,
+		unmarshal:   unmarshal,
+	}
+}
+
+func (c *converter) ContentType() string {
+	return c.contentType
+}
+
+func (c *converter) Marshal(v interface{}) ([]byte, error) {
+	return c.marshal(v)
+}
+
+func (c *converter) Unmarshal(data []byte, v interface{}) error {
+	return c.unmarshal(data, v)
+}
+
+var (
+	// JSONConverter encodes bodies as JSON with encoding/json. It is the converter of
+	// clients which do not configure one.
+	JSONConverter = NewConverter("application/json", json.Marshal, json.Unmarshal)
+
+	// XMLConverter encodes bodies as XML with encoding/xml.
+	XMLConverter = NewConverter("application/xml", xml.Marshal, xml.Unmarshal)
+
+	// FormConverter encodes bodies as URL encoded forms, see MarshalForm and UnmarshalForm.
+	FormConverter = NewConverter("application/x-www-form-urlencoded", MarshalForm, UnmarshalForm)
+)
+
+// ConverterRegistry is a registry of named converters. It is safe for concurrent use.
+type ConverterRegistry struct {
+	mu         sync.RWMutex
+	converters map[string]Converter
+}
+
+var converterRegistry *ConverterRegistry
+
+func init() {
+	converterRegistry = NewConverterRegistry()
+	converterRegistry.Register("json", JSONConverter)
+	converterRegistry.Register("xml", XMLConverter)
+	converterRegistry.Register("form", FormConverter)
+}
+
+func NewConverterRegistry() *ConverterRegistry {
+	return &ConverterRegistry{
+		converters: make(map[string]Converter),
+	}
+}
+
+// Register registers the converter with the name, replacing any converter previously
+// registered with the same name.
+func (r *ConverterRegistry) Register(name string, converter Converter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.converters[name] = converter
+}
+
+// Get returns the converter registered with the name or nil if there is none.
+func (r *ConverterRegistry) Get(name string) Converter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.converters[name]
+}
+
+// RegisterConverter registers the converter used by requests annotated with
+// @CONVERTER(name). The json, xml and form converters are registered by default.
+func RegisterConverter(name string, converter Converter) {
+	converterRegistry.Register(name, converter)
+}
+
+// GetConverter returns the converter registered with the name.
+func GetConverter(name string) Converter {
+	return converterRegistry.Get(name)
+}
+
+// ResolveConverter returns the converter a request of the client encodes and decodes
+// bodies with. A request annotated with @CONVERTER(name) uses the converter registered
+// with the name, any other request uses the client's converter or JSONConverter when the
+// client does not configure one.
+func ResolveConverter(client Client, name string) (Converter, error) {
+	if name != "" {
+		converter := GetConverter(name)
+		if converter == nil {
+			return nil, fmt.Errorf("A converter named %s has not been registered yet. You must call restclient.RegisterConverter first", name)
+		}
+		return converter, nil
+	}
+	if converter := client.Converter(); converter != nil {
+		return converter, nil
+	}
+	return JSONConverter, nil
+}
+
+// DecodeResponse decodes the body of the response in to the value v points to with the
+// converter. A response without a body leaves the value unchanged. When v points to a
+// pointer the body is decoded in to the value it points to, which is allocated if the
+// pointer is nil, so converters always receive a pointer to the response.
+func DecodeResponse(converter Converter, response *http.Response, v interface{}) error {
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if ptr := reflect.ValueOf(v); ptr.Kind() == reflect.Ptr && !ptr.IsNil() && ptr.Elem().Kind() == reflect.Ptr {
+		if ptr.Elem().IsNil() {
+			ptr.Elem().Set(reflect.New(ptr.Elem().Type().Elem()))
+		}
+		v = ptr.Elem().Interface()
+	}
+	return converter.Unmarshal(data, v)
+}
+
+// MarshalForm encodes url.Values, map[string]string, map[string][]string or a struct as
+// a URL encoded form. Struct fields are named by their form tag, or their name when they
+// have none, and are sent as their ParamValues. A field tagged form:"-" is skipped and
+// the omitempty option omits a zero value, for example form:"name,omitempty".
+func MarshalForm(v interface{}) ([]byte, error) {
+	switch form := v.(type) {
+	case url.Values:
+		return []byte(form.Encode()), nil
+	case map[string][]string:
+		return []byte(url.Values(form).Encode()), nil
+	case map[string]string:
+		values := url.Values{}
+		for key, value := range form {
+			values.Set(key, value)
+		}
+		return []byte(values.Encode()), nil
+	}
+
+	s := reflect.ValueOf(v)
+	for s.Kind() == reflect.Ptr && !s.IsNil() {
+		s = s.Elem()
+	}
+	if s.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form converter can not marshal %T", v)
+	}
+	values := url.Values{}
+	for i := 0; i < s.NumField(); i++ {
+		name, omitEmpty, ok := formField(s.Type().Field(i))
+		if !ok {
+			continue
+		}
+		values[name] = append(values[name], ParamValues(s.Field(i).Interface(), omitEmpty)...)
+		if len(values[name]) == 0 {
+			delete(values, name)
+		}
+	}
+	return []byte(values.Encode()), nil
+}
+
+// UnmarshalForm decodes a URL encoded form in to a *url.Values, *map[string]string,
+// *map[string][]string or a pointer to a struct. Struct fields are named like MarshalForm
+// names them and may be strings, booleans, numbers or slices of them.
+func UnmarshalForm(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch form := v.(type) {
+	case *url.Values:
+		*form = values
+		return nil
+	case *map[string][]string:
+		*form = values
+		return nil
+	case *map[string]string:
+		*form = make(map[string]string, len(values))
+		for key := range values {
+			(*form)[key] = values.Get(key)
+		}
+		return nil
+	}
+
+	s := reflect.ValueOf(v)
+	if s.Kind() != reflect.Ptr || s.IsNil() || s.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form converter can not unmarshal in to %T", v)
+	}
+	s = s.Elem()
+	for i := 0; i < s.NumField(); i++ {
+		name, _, ok := formField(s.Type().Field(i))
+		if !ok || len(values[name]) == 0 {
+			continue
+		}
+		if err := setFormValue(s.Field(i), values[name]); err != nil {
+			return fmt.Errorf("form field %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// formField returns the form name of the struct field and whether its zero value is
+// omitted. ok is false for unexported fields and fields tagged form:"-".
+func formField(f reflect.StructField) (name string, omitEmpty bool, ok bool) {
+	if f.PkgPath != "" {
+		return "", false, false
+	}
+	tag := f.Tag.Get("form")
+	if tag == "-" {
+		return "", false, false
+	}
+	options := strings.Split(tag, ",")
+	name = options[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, option := range options[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, true
+}
+
+// setFormValue sets the field to the form values. Only a slice field receives more
+// than the first value.
+func setFormValue(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setFormValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(field.Type().Elem())
+		if err := setFormValue(value.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+
+	value := values[0]
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Slice:
+		field.SetBytes([]byte(value))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package restclient
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type photo struct {
+	XMLName xml.Name `json:"-" xml:"photo" form:"-"`
+	ID      int      `json:"id" xml:"id" form:"id"`
+	Title   string   `json:"title" xml:"title" form:"title,omitempty"`
+	Tags    []string `json:"tags" xml:"tag" form:"tag"`
+	Private *bool    `json:"private" xml:"private" form:"private"`
+	Ignored string   `json:"-" xml:"-" form:"-"`
+}
+
+func TestConverters(t *testing.T) {
+	private := true
+	value := photo{ID: 42, Title: "Sunset", Tags: []string{"sea", "sky"}, Private: &private, Ignored: "ignored"}
+
+	var testCases = []struct {
+		converter   Converter
+		contentType string
+		encoded     string
+	}{
+		{JSONConverter, "application/json", `{"id":42,"title":"Sunset","tags":["sea","sky"],"private":true}`},
+		{XMLConverter, "application/xml", `<photo><id>42</id><title>Sunset</title><tag>sea</tag><tag>sky</tag><private>true</private></photo>`},
+		{FormConverter, "application/x-www-form-urlencoded", `id=42&private=true&tag=sea&tag=sky&title=Sunset`},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.contentType, tc.converter.ContentType())
+		data, err := tc.converter.Marshal(value)
+		if !assert.NoError(t, err, tc.contentType) {
+			continue
+		}
+		assert.Equal(t, tc.encoded, string(data), tc.contentType)
+
+		var decoded photo
+		if assert.NoError(t, tc.converter.Unmarshal(data, &decoded), tc.contentType) {
+			assert.Equal(t, 42, decoded.ID, tc.contentType)
+			assert.Equal(t, "Sunset", decoded.Title, tc.contentType)
+			assert.Equal(t, []string{"sea", "sky"}, decoded.Tags, tc.contentType)
+			assert.Equal(t, &private, decoded.Private, tc.contentType)
+			assert.Equal(t, "", decoded.Ignored, tc.contentType)
+		}
+	}
+}
+
+func TestFormConverter(t *testing.T) {
+	data, err := MarshalForm(url.Values{"b": {"2"}, "a": {"1", "3"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "a=1&a=3&b=2", string(data))
+
+	data, err = MarshalForm(map[string]string{"q": "cats & dogs"})
+	assert.NoError(t, err)
+	assert.Equal(t, "q=cats+%26+dogs", string(data))
+
+	// Empty values are omitted when requested
+	data, err = MarshalForm(&photo{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "id=1", string(data))
+
+	_, err = MarshalForm(42)
+	assert.Error(t, err)
+
+	var values url.Values
+	assert.NoError(t, UnmarshalForm([]byte("a=1&a=2"), &values))
+	assert.Equal(t, url.Values{"a": {"1", "2"}}, values)
+
+	var fields map[string]string
+	assert.NoError(t, UnmarshalForm([]byte("a=1&a=2&b=3"), &fields))
+	assert.Equal(t, map[string]string{"a": "1", "b": "3"}, fields)
+
+	var decoded photo
+	assert.Error(t, UnmarshalForm([]byte("id=first"), &decoded))
+	assert.Error(t, UnmarshalForm([]byte("id=1"), decoded))
+}
+
+func TestResolveConverter(t *testing.T) {
+	custom := NewConverter("application/x-custom", nil, nil)
+	RegisterConverter("custom", custom)
+	defer RegisterConverter("custom", nil)
+
+	client := NewDefaultClient("", false, http.DefaultClient)
+	converter, err := ResolveConverter(client, "")
+	assert.NoError(t, err)
+	assert.Equal(t, JSONConverter, converter)
+
+	client = NewDefaultClient("", false, http.DefaultClient, WithConverter(XMLConverter))
+	converter, err = ResolveConverter(client, "")
+	assert.NoError(t, err)
+	assert.Equal(t, XMLConverter, converter)
+
+	// The converter of the request overrides the client's converter
+	converter, err = ResolveConverter(client, "custom")
+	assert.NoError(t, err)
+	assert.Equal(t, custom, converter)
+	converter, err = ResolveConverter(client, "form")
+	assert.NoError(t, err)
+	assert.Equal(t, FormConverter, converter)
+
+	_, err = ResolveConverter(client, "msgpack")
+	assert.Error(t, err)
+}
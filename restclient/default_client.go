@@ -3,19 +3,51 @@ package restclient
 import "net/http"
 
 type DefaultClient struct {
-	baseURL string
-	debug   bool
-	client  *http.Client
+	baseURL      string
+	debug        bool
+	client       *http.Client
+	interceptors []Interceptor
+	retryPolicy  *RetryPolicy
+	converter    Converter
 }
 
-func NewDefaultClient(baseURL string, debug bool, client *http.Client) Client {
-	return &DefaultClient{
-		baseURL,
-		debug,
-		client,
+// Option configures optional behaviour of a DefaultClient.
+type Option func(c *DefaultClient)
+
+// WithInterceptors appends the interceptors to the client's interceptor chain.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *DefaultClient) {
+		c.interceptors = append(c.interceptors, interceptors...)
 	}
 }
 
+// WithRetryPolicy retries failed requests according to the policy.
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(c *DefaultClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithConverter converts the request and response bodies of the client's requests with
+// the converter unless a request is annotated with @CONVERTER.
+func WithConverter(converter Converter) Option {
+	return func(c *DefaultClient) {
+		c.converter = converter
+	}
+}
+
+func NewDefaultClient(baseURL string, debug bool, client *http.Client, options ...Option) Client {
+	c := &DefaultClient{
+		baseURL: baseURL,
+		debug:   debug,
+		client:  client,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
 func (c *DefaultClient) BaseURL() string {
 	return c.baseURL
 }
@@ -27,3 +59,15 @@ func (c *DefaultClient) Debug() bool {
 func (c *DefaultClient) HttpClient() *http.Client {
 	return c.client
 }
+
+func (c *DefaultClient) Interceptors() []Interceptor {
+	return c.interceptors
+}
+
+func (c *DefaultClient) RetryPolicy() *RetryPolicy {
+	return c.retryPolicy
+}
+
+func (c *DefaultClient) Converter() Converter {
+	return c.converter
+}
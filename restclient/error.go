@@ -0,0 +1,56 @@
+package restclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// HTTPError is returned by a request when the server responds with a status code which
+// the request does not consider successful. The response body is read in its entirety
+// so it remains available after the response has been closed.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	URL        string
+}
+
+// NewHTTPError creates an HTTPError from the response. The response body is consumed.
+func NewHTTPError(response *http.Response) *HTTPError {
+	e := &HTTPError{
+		StatusCode: response.StatusCode,
+		Status:     response.Status,
+		Header:     response.Header,
+	}
+	if response.Request != nil && response.Request.URL != nil {
+		e.URL = response.Request.URL.String()
+	}
+	if response.Body != nil {
+		// The body is informational, a partial read is still useful
+		e.Body, _ = ioutil.ReadAll(response.Body)
+	}
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	if e.URL == "" {
+		return fmt.Sprintf("unexpected response status %s", e.Status)
+	}
+	return fmt.Sprintf("unexpected response status %s from %s", e.Status, e.URL)
+}
+
+// IsSuccess reports whether the status code is one of the success codes. When no
+// success codes are provided any 2xx status code is considered successful.
+func IsSuccess(statusCode int, successCodes ...int) bool {
+	if len(successCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	for _, code := range successCodes {
+		if statusCode == code {
+			return true
+		}
+	}
+	return false
+}
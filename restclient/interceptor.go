@@ -0,0 +1,77 @@
+package restclient
+
+import "net/http"
+
+// Interceptor observes, and may modify, every request sent by a Client and every
+// response it receives. Interceptors are applied in the order they are registered
+// with the client for requests and in reverse order for responses.
+type Interceptor interface {
+	// InterceptRequest is called before the request is sent. Returning an error
+	// aborts the request.
+	InterceptRequest(request *http.Request) error
+
+	// InterceptResponse is called once the response has been received. Returning an
+	// error fails the request and closes the response body.
+	InterceptResponse(response *http.Response) error
+}
+
+// RequestInterceptorFunc is an Interceptor which only intercepts requests.
+type RequestInterceptorFunc func(request *http.Request) error
+
+func (f RequestInterceptorFunc) InterceptRequest(request *http.Request) error {
+	return f(request)
+}
+
+func (f RequestInterceptorFunc) InterceptResponse(response *http.Response) error {
+	return nil
+}
+
+// ResponseInterceptorFunc is an Interceptor which only intercepts responses.
+type ResponseInterceptorFunc func(response *http.Response) error
+
+func (f ResponseInterceptorFunc) InterceptRequest(request *http.Request) error {
+	return nil
+}
+
+func (f ResponseInterceptorFunc) InterceptResponse(response *http.Response) error {
+	return f(response)
+}
+
+// send sends the request once after passing it through the client's interceptors.
+// retryable reports whether the request was sent, in which case a failure may be retried.
+func send(client Client, request *http.Request) (response *http.Response, err error, retryable bool) {
+	interceptors := client.Interceptors()
+	for _, interceptor := range interceptors {
+		if err := interceptor.InterceptRequest(request); err != nil {
+			if request.Body != nil {
+				request.Body.Close()
+			}
+			return nil, err, false
+		}
+	}
+
+	if client.Debug() {
+		DebugRequest(request)
+	}
+
+	httpClient := client.HttpClient()
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err = httpClient.Do(request)
+	if err != nil {
+		return nil, err, true
+	}
+
+	if client.Debug() {
+		DebugResponse(response)
+	}
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		if err := interceptors[i].InterceptResponse(response); err != nil {
+			response.Body.Close()
+			return nil, err, false
+		}
+	}
+	return response, nil, true
+}
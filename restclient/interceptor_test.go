@@ -0,0 +1,82 @@
+package restclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingInterceptor struct {
+	name  string
+	calls *[]string
+}
+
+func (i recordingInterceptor) InterceptRequest(request *http.Request) error {
+	*i.calls = append(*i.calls, "request "+i.name)
+	request.Header.Add("X-Interceptor", i.name)
+	return nil
+}
+
+func (i recordingInterceptor) InterceptResponse(response *http.Response) error {
+	*i.calls = append(*i.calls, "response "+i.name)
+	return nil
+}
+
+func TestDoInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["X-Interceptor"] = r.Header["X-Interceptor"]
+	}))
+	defer server.Close()
+
+	var calls []string
+	client := NewDefaultClient(server.URL, false, server.Client(), WithInterceptors(
+		recordingInterceptor{"first", &calls},
+		recordingInterceptor{"second", &calls},
+	))
+
+	request, err := http.NewRequest("GET", server.URL, nil)
+	assert.NoError(t, err)
+
+	response, err := Do(client, request)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	assert.Equal(t, []string{"request first", "request second", "response second", "response first"}, calls)
+	assert.Equal(t, []string{"first", "second"}, response.Header["X-Interceptor"])
+}
+
+func TestDoInterceptorError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	errRequest := errors.New("request rejected")
+	errResponse := errors.New("response rejected")
+
+	client := NewDefaultClient(server.URL, false, server.Client(), WithInterceptors(
+		RequestInterceptorFunc(func(request *http.Request) error {
+			return errRequest
+		}),
+	))
+	request, err := http.NewRequest("GET", server.URL, nil)
+	assert.NoError(t, err)
+	_, err = Do(client, request)
+	assert.Equal(t, errRequest, err)
+	assert.Equal(t, 0, requests)
+
+	client = NewDefaultClient(server.URL, false, server.Client(), WithInterceptors(
+		ResponseInterceptorFunc(func(response *http.Response) error {
+			return errResponse
+		}),
+	))
+	request, err = http.NewRequest("GET", server.URL, nil)
+	assert.NoError(t, err)
+	_, err = Do(client, request)
+	assert.Equal(t, errResponse, err)
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,257 @@
+package restclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultPartContentType = "application/octet-stream"
+
+// Part is a single part of a multipart/form-data request body. A part without a
+// FileName is sent as a form field, otherwise it is sent as a file.
+type Part struct {
+	Name        string
+	FileName    string
+	ContentType string
+	Content     io.Reader
+}
+
+// NewParts returns the parts a multipart request parameter is sent as. Readers,
+// including files, are sent as file parts which are named after the base name of
+// the file or otherwise the part name unless fileName is set. A Part is sent as is
+// under the name of the parameter. Slices and arrays other than byte slices are sent
+// as a part per element and any other value is sent as a form field.
+// A file part without a content type is sent with the content type of its file name
+// extension or application/octet-stream.
+func NewParts(name string, param interface{}, fileName, contentType string) []Part {
+	v := reflect.ValueOf(param)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil
+	}
+
+	switch p := param.(type) {
+	case Part:
+		p.Name = name
+		return []Part{p}
+	case *Part:
+		return NewParts(name, *p, fileName, contentType)
+	case *os.File:
+		if fileName == "" {
+			fileName = filepath.Base(p.Name())
+		}
+		return []Part{{Name: name, FileName: fileName, ContentType: contentType, Content: p}}
+	case io.Reader:
+		if fileName == "" {
+			fileName = name
+		}
+		return []Part{{Name: name, FileName: fileName, ContentType: contentType, Content: p}}
+	case []byte:
+		return []Part{{Name: name, FileName: fileName, ContentType: contentType, Content: bytes.NewReader(p)}}
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		var parts []Part
+		for i := 0; i < v.Len(); i++ {
+			parts = append(parts, NewParts(name, v.Index(i).Interface(), fileName, contentType)...)
+		}
+		return parts
+	}
+
+	var parts []Part
+	for _, value := range ParamValues(param, false) {
+		parts = append(parts, Part{Name: name, FileName: fileName, ContentType: contentType, Content: strings.NewReader(value)})
+	}
+	return parts
+}
+
+// FormParts returns the form fields of a request which also sends parts, as fields of
+// its multipart body. The fields are sorted by name like an encoded form.
+func FormParts(values url.Values) []Part {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []Part
+	for _, name := range names {
+		for _, value := range values[name] {
+			parts = append(parts, Part{Name: name, Content: strings.NewReader(value)})
+		}
+	}
+	return parts
+}
+
+// header returns the MIME header the part is written with.
+func (p Part) header() textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	disposition := map[string]string{"name": p.Name}
+	contentType := p.ContentType
+	if p.FileName != "" {
+		disposition["filename"] = p.FileName
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(p.FileName))
+		}
+		if contentType == "" {
+			contentType = defaultPartContentType
+		}
+	}
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", disposition))
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	return h
+}
+
+// NewMultipartRequest creates a request which streams the parts as its
+// multipart/form-data body. The parts are written through a pipe while the body is
+// read so their content is never held in memory. The Content-Type header is set
+// including the boundary of the body.
+// The request can only be retried when the content of every part is an io.Seeker,
+// such as a file, in which case every attempt starts at the offset the content had
+// when the request was created. The parts are not closed.
+func NewMultipartRequest(ctx context.Context, method, url string, parts []Part) (*http.Request, error) {
+	writer := multipart.NewWriter(ioutil.Discard)
+	body := &multipartBody{parts: parts, boundary: writer.Boundary()}
+	seekable := body.offsets()
+
+	request, err := http.NewRequestWithContext(ctx, method, url, body.open())
+	if err != nil {
+		return nil, err
+	}
+	if seekable {
+		request.GetBody = body.rewind
+	}
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request, nil
+}
+
+// multipartBody writes the parts of a multipart request body.
+type multipartBody struct {
+	parts    []Part
+	boundary string
+	starts   []int64
+
+	mu      sync.Mutex
+	current *multipartReader
+}
+
+// offsets records the offset of the content of every part, it reports whether the
+// content of every part can be rewound.
+func (b *multipartBody) offsets() bool {
+	b.starts = make([]int64, len(b.parts))
+	for i, part := range b.parts {
+		seeker, ok := part.Content.(io.Seeker)
+		if !ok {
+			return false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return false
+		}
+		b.starts[i] = offset
+	}
+	return true
+}
+
+// open returns a reader of the body. The parts are only written once the body is read.
+func (b *multipartBody) open() io.ReadCloser {
+	pr, pw := io.Pipe()
+	r := &multipartReader{pr: pr, done: make(chan struct{})}
+	r.start = func() {
+		go func() {
+			defer close(r.done)
+			pw.CloseWithError(b.write(pw))
+		}()
+	}
+
+	b.mu.Lock()
+	b.current = r
+	b.mu.Unlock()
+	return r
+}
+
+// rewind returns a new reader of the body after the previous reader has stopped
+// writing the parts.
+func (b *multipartBody) rewind() (io.ReadCloser, error) {
+	b.mu.Lock()
+	previous := b.current
+	b.mu.Unlock()
+	if previous != nil {
+		previous.Close()
+		previous.wait()
+	}
+
+	for i, part := range b.parts {
+		if _, err := part.Content.(io.Seeker).Seek(b.starts[i], io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return b.open(), nil
+}
+
+func (b *multipartBody) write(w io.Writer) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	for _, part := range b.parts {
+		pw, err := writer.CreatePart(part.header())
+		if err != nil {
+			return err
+		}
+		if part.Content == nil {
+			continue
+		}
+		if _, err := io.Copy(pw, part.Content); err != nil {
+			return fmt.Errorf("multipart %s: %v", part.Name, err)
+		}
+	}
+	return writer.Close()
+}
+
+// multipartReader is the read end of the pipe the parts are written to. Writing
+// starts with the first read so a body which is never read does not block a goroutine.
+type multipartReader struct {
+	pr      *io.PipeReader
+	start   func()
+	once    sync.Once
+	started bool
+	done    chan struct{}
+}
+
+func (r *multipartReader) Read(p []byte) (int, error) {
+	r.once.Do(func() {
+		r.started = true
+		r.start()
+	})
+	return r.pr.Read(p)
+}
+
+// Close stops the writing of the parts, a body which has not been read will never
+// be written.
+func (r *multipartReader) Close() error {
+	r.once.Do(func() {})
+	return r.pr.Close()
+}
+
+// wait waits until the parts are no longer written.
+func (r *multipartReader) wait() {
+	r.once.Do(func() {})
+	if r.started {
+		<-r.done
+	}
+}
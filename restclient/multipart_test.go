@@ -0,0 +1,179 @@
+package restclient
+
+import (
+	"context"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewParts(t *testing.T) {
+	file, err := ioutil.TempFile("", "photo-*.png")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var nilFile *os.File
+	reader := strings.NewReader("data")
+	var testCases = []struct {
+		param       interface{}
+		fileName    string
+		contentType string
+		parts       []Part
+	}{
+		{nil, "", "", nil},
+		{nilFile, "", "", nil},
+		{"value", "", "", []Part{{Name: "p", Content: strings.NewReader("value")}}},
+		{42, "", "", []Part{{Name: "p", Content: strings.NewReader("42")}}},
+		{[]int{1, 2}, "", "", []Part{{Name: "p", Content: strings.NewReader("1")}, {Name: "p", Content: strings.NewReader("2")}}},
+		{[]byte("data"), "data.bin", "", []Part{{Name: "p", FileName: "data.bin", Content: strings.NewReader("data")}}},
+		{reader, "", "", []Part{{Name: "p", FileName: "p", Content: reader}}},
+		{reader, "data.txt", "text/plain", []Part{{Name: "p", FileName: "data.txt", ContentType: "text/plain", Content: reader}}},
+		{file, "", "", []Part{{Name: "p", FileName: filepath.Base(file.Name()), Content: file}}},
+		{Part{Name: "other", FileName: "a.txt", Content: reader}, "", "", []Part{{Name: "p", FileName: "a.txt", Content: reader}}},
+	}
+
+	for _, tc := range testCases {
+		parts := NewParts("p", tc.param, tc.fileName, tc.contentType)
+		if !assert.Len(t, parts, len(tc.parts), "%v", tc.param) {
+			continue
+		}
+		for i, part := range parts {
+			expected := tc.parts[i]
+			assert.Equal(t, expected.Name, part.Name)
+			assert.Equal(t, expected.FileName, part.FileName)
+			assert.Equal(t, expected.ContentType, part.ContentType)
+			if expected.Content != reader && expected.Content != file {
+				// Values are sent as a reader of their content
+				assert.Equal(t, partContent(expected), partContent(part))
+			} else {
+				assert.Equal(t, expected.Content, part.Content)
+			}
+		}
+	}
+}
+
+// multipartServer responds with the status code to the first failures requests and
+// with 200 OK afterwards. The parts of all requests are recorded.
+func multipartServer(t *testing.T, failures int, statusCode int) (*httptest.Server, *[][]Part) {
+	var requests [][]Part
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/form-data", mediaType)
+
+		var parts []Part
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			p, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := ioutil.ReadAll(p)
+			parts = append(parts, Part{
+				Name:        p.FormName(),
+				FileName:    p.FileName(),
+				ContentType: p.Header.Get("Content-Type"),
+				Content:     strings.NewReader(string(data)),
+			})
+		}
+		requests = append(requests, parts)
+		if len(requests) <= failures {
+			w.WriteHeader(statusCode)
+		}
+	}))
+	return server, &requests
+}
+
+func partContent(part Part) string {
+	data, _ := ioutil.ReadAll(part.Content)
+	return string(data)
+}
+
+func TestFormParts(t *testing.T) {
+	parts := FormParts(url.Values{"title": {"sunset"}, "tag": {"sea", "sky"}})
+	if assert.Len(t, parts, 3) {
+		assert.Equal(t, "tag", parts[0].Name)
+		assert.Equal(t, "sea", partContent(parts[0]))
+		assert.Equal(t, "tag", parts[1].Name)
+		assert.Equal(t, "sky", partContent(parts[1]))
+		assert.Equal(t, "title", parts[2].Name)
+		assert.Equal(t, "", parts[2].FileName)
+		assert.Equal(t, "sunset", partContent(parts[2]))
+	}
+	assert.Empty(t, FormParts(nil))
+}
+
+func TestNewMultipartRequest(t *testing.T) {
+	server, requests := multipartServer(t, 0, 0)
+	defer server.Close()
+	client := NewDefaultClient(server.URL, false, server.Client())
+
+	var parts []Part
+	parts = append(parts, NewParts("caption", "sunset", "", "")...)
+	parts = append(parts, NewParts("photo", ioutil.NopCloser(strings.NewReader("jpeg")), "photo.jpg", "")...)
+	parts = append(parts, NewParts("raw", strings.NewReader("raw"), "", "")...)
+	parts = append(parts, NewParts("meta", strings.NewReader("{}"), "", "application/json")...)
+	request, err := NewMultipartRequest(context.Background(), "POST", server.URL, parts)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Nil(t, request.GetBody)
+
+	response, err := Do(client, request)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+	}
+	if !assert.Len(t, *requests, 1) || !assert.Len(t, (*requests)[0], 4) {
+		return
+	}
+	received := (*requests)[0]
+	assert.Equal(t, "caption", received[0].Name)
+	assert.Equal(t, "", received[0].FileName)
+	assert.Equal(t, "", received[0].ContentType)
+	assert.Equal(t, "sunset", partContent(received[0]))
+	assert.Equal(t, "photo.jpg", received[1].FileName)
+	assert.Equal(t, "image/jpeg", received[1].ContentType)
+	assert.Equal(t, "jpeg", partContent(received[1]))
+	assert.Equal(t, "raw", received[2].FileName)
+	assert.Equal(t, defaultPartContentType, received[2].ContentType)
+	assert.Equal(t, "application/json", received[3].ContentType)
+	assert.Equal(t, "{}", partContent(received[3]))
+}
+
+func TestNewMultipartRequestRetry(t *testing.T) {
+	server, requests := multipartServer(t, 1, http.StatusServiceUnavailable)
+	defer server.Close()
+	client := NewDefaultClient(server.URL, false, server.Client(), WithRetryPolicy(testRetryPolicy().ForEndpoint(2)))
+
+	content := strings.NewReader("skipped content")
+	content.Seek(int64(len("skipped ")), 0)
+	request, err := NewMultipartRequest(context.Background(), "POST", server.URL, NewParts("file", content, "a.txt", ""))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	response, err := Do(client, request)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+	if assert.Len(t, *requests, 2) {
+		for _, parts := range *requests {
+			if assert.Len(t, parts, 1) {
+				assert.Equal(t, "content", partContent(parts[0]))
+			}
+		}
+	}
+}
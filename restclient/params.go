@@ -0,0 +1,100 @@
+package restclient
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParamValues returns the values a request parameter is sent as. Slices and arrays
+// are sent as a value per element and pointers as the value they point to. A nil
+// pointer, slice or interface has no values. When omitEmpty is set a zero value,
+// including an empty slice, has no values either. A pointer to a zero value is not
+// empty so it can be used to send zero values of optional parameters.
+//
+// A time.Time is formatted as RFC 3339 and a time.Duration like 1h30m. Any other value
+// which implements encoding.TextMarshaler or fmt.Stringer is sent as its text, which
+// takes precedence over sending a slice per element. Booleans and numbers are formatted
+// with strconv, floats without an exponent. Any other value, such as a map or a struct,
+// is formatted with fmt.Sprint; such parameters are rejected when the package is parsed
+// with its type information.
+func ParamValues(param interface{}, omitEmpty bool) []string {
+	v := reflect.ValueOf(param)
+	if !v.IsValid() || (omitEmpty && v.IsZero()) {
+		return nil
+	}
+	for {
+		if value, ok := textValue(v); ok {
+			return []string{value}
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			// A byte slice is a single value
+			return []string{string(v.Bytes())}
+		}
+		var values []string
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, ParamValues(v.Index(i).Interface(), false)...)
+		}
+		return values
+	case reflect.String:
+		return []string{v.String()}
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return []string{strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())}
+	}
+	return []string{fmt.Sprint(v.Interface())}
+}
+
+// ParamValue returns the single value a path or header parameter is sent as, which
+// is its ParamValues joined with commas. ok is false when the parameter has no values,
+// for example a nil pointer, in which case the parameter is not sent.
+func ParamValue(param interface{}) (value string, ok bool) {
+	values := ParamValues(param, false)
+	if len(values) == 0 {
+		return "", false
+	}
+	return strings.Join(values, ","), true
+}
+
+// textValue returns the text of a value which formats itself. Nil pointers are left to
+// the caller as they have no values.
+func textValue(v reflect.Value) (string, bool) {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "", false
+	}
+	switch value := v.Interface().(type) {
+	case time.Time:
+		return value.Format(time.RFC3339Nano), true
+	case time.Duration:
+		return value.String(), true
+	case encoding.TextMarshaler:
+		text, err := value.MarshalText()
+		if err != nil {
+			// The value is formatted by its kind instead
+			return "", false
+		}
+		return string(text), true
+	case fmt.Stringer:
+		return value.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,106 @@
+package restclient
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// level is a Stringer whose underlying type would otherwise be formatted as a number
+type level int
+
+func (l level) String() string {
+	return [...]string{"low", "high"}[l]
+}
+
+// tags is a Stringer slice which is sent as a single value
+type tags []string
+
+func (t tags) String() string {
+	return strings.Join(t, " ")
+}
+
+func TestParamValues(t *testing.T) {
+	zero := 0
+	page := 2
+	var nilSlice []string
+	var nilPointer *int
+	var nilTime *time.Time
+	date := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	precise := time.Date(2024, 3, 1, 12, 30, 0, 500000000, time.FixedZone("CET", 3600))
+	yes := true
+
+	var testCases = []struct {
+		param     interface{}
+		omitEmpty bool
+		values    []string
+	}{
+		{"cats", false, []string{"cats"}},
+		{42, false, []string{"42"}},
+		{true, false, []string{"true"}},
+		{[]string{"a", "b"}, false, []string{"a", "b"}},
+		{[2]int{1, 2}, false, []string{"1", "2"}},
+		{[]*int{&page, nil}, false, []string{"2"}},
+		{[]byte("raw"), false, []string{"raw"}},
+		{&page, false, []string{"2"}},
+		{nilPointer, false, nil},
+		{nilSlice, false, nil},
+		{nil, false, nil},
+		// Zero values are only omitted when requested
+		{0, false, []string{"0"}},
+		{0, true, nil},
+		{"", true, nil},
+		{[]string{}, true, nil},
+		{nilSlice, true, nil},
+		// A pointer to a zero value is not empty
+		{&zero, true, []string{"0"}},
+		// Booleans and numbers are formatted with strconv
+		{&yes, false, []string{"true"}},
+		{uint8(7), false, []string{"7"}},
+		{1000000.0, false, []string{"1000000"}},
+		{float32(0.25), false, []string{"0.25"}},
+		// Times are formatted as RFC 3339 and durations like 1h30m
+		{date, false, []string{"2024-03-01T12:30:00Z"}},
+		{&precise, false, []string{"2024-03-01T12:30:00.5+01:00"}},
+		{[]time.Time{date}, false, []string{"2024-03-01T12:30:00Z"}},
+		{nilTime, false, nil},
+		{time.Time{}, true, nil},
+		{90 * time.Minute, false, []string{"1h30m0s"}},
+		// Values which format themselves are sent as their text
+		{net.ParseIP("127.0.0.1"), false, []string{"127.0.0.1"}},
+		{level(1), false, []string{"high"}},
+		{tags{"a", "b"}, false, []string{"a b"}},
+		{[]level{0, 1}, false, []string{"low", "high"}},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.values, ParamValues(tc.param, tc.omitEmpty), "%#v", tc.param)
+	}
+}
+
+func TestParamValue(t *testing.T) {
+	var nilPointer *int
+	id := 7
+
+	var testCases = []struct {
+		param interface{}
+		value string
+		ok    bool
+	}{
+		{"photo", "photo", true},
+		{&id, "7", true},
+		{[]int{1, 2, 3}, "1,2,3", true},
+		{"", "", true},
+		{nilPointer, "", false},
+		{[]string{}, "", false},
+	}
+
+	for _, tc := range testCases {
+		value, ok := ParamValue(tc.param)
+		assert.Equal(t, tc.value, value, "%#v", tc.param)
+		assert.Equal(t, tc.ok, ok, "%#v", tc.param)
+	}
+}
@@ -0,0 +1,134 @@
+package restclient
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried. A request is retried when
+// it fails with a transport error or when the response status code is one of
+// RetryOnStatus. Only requests with idempotent methods are retried unless
+// RetryNonIdempotent is set.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including the
+	// first attempt. A value less than 2 disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. A Retry-After response header
+	// requesting a longer delay stops retrying.
+	MaxBackoff time.Duration
+
+	// Multiplier scales the delay after each retry.
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to the given fraction of the delay.
+	// For example 0.2 results in a delay between 80% and 120% of the backoff.
+	Jitter float64
+
+	// RetryOnStatus lists the response status codes which are retried.
+	RetryOnStatus []int
+
+	// RetryNonIdempotent allows requests with non-idempotent methods, such as POST,
+	// to be retried.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a policy which sends a request at most 3 times with an
+// exponential backoff starting at 100ms. Rate limited and unavailable responses are
+// retried.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryOnStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// ForEndpoint returns a copy of the policy for an endpoint annotated with
+// @RETRY(maxAttempts). The endpoint explicitly opted in to retries so it is retried
+// regardless of its method. A nil policy is replaced by DefaultRetryPolicy.
+func (p *RetryPolicy) ForEndpoint(maxAttempts int) *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if p != nil {
+		copied := *p
+		policy = &copied
+	}
+	policy.MaxAttempts = maxAttempts
+	policy.RetryNonIdempotent = true
+	return policy
+}
+
+// backoff returns the delay before the retry following the given number of attempts.
+func (p *RetryPolicy) backoff(attempts int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempts-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// retryStatus reports whether a response with the status code is retried.
+func (p *RetryPolicy) retryStatus(statusCode int) bool {
+	for _, code := range p.RetryOnStatus {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// retryMethod reports whether the request may be sent more than once.
+func (p *RetryPolicy) retryMethod(request *http.Request) bool {
+	if p.RetryNonIdempotent {
+		return true
+	}
+	switch request.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	// Same convention as net/http for requests which carry an idempotency key
+	_, hasKey := request.Header["Idempotency-Key"]
+	_, hasXKey := request.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
+
+// retryAfter returns the delay requested by the response's Retry-After header, which
+// is either a number of seconds or an HTTP date.
+func retryAfter(response *http.Response, now time.Time) (time.Duration, bool) {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,168 @@
+package restclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.Jitter = 0
+	return policy
+}
+
+// failingServer responds with the status code to the first failures requests and
+// with 200 OK afterwards. The bodies of all requests are recorded.
+func failingServer(failures int, statusCode int, header http.Header) (*httptest.Server, *[]string) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statusCode)
+		}
+	}))
+	return server, &bodies
+}
+
+func TestDoRetry(t *testing.T) {
+	var testCases = []struct {
+		method     string
+		failures   int
+		statusCode int
+		policy     *RetryPolicy
+		requests   int
+		status     int
+	}{
+		// Retries disabled
+		{"GET", 1, http.StatusServiceUnavailable, nil, 1, http.StatusServiceUnavailable},
+		// Retried until successful
+		{"GET", 2, http.StatusServiceUnavailable, testRetryPolicy(), 3, http.StatusOK},
+		// Retried until the attempts are exhausted
+		{"GET", 5, http.StatusServiceUnavailable, testRetryPolicy(), 3, http.StatusServiceUnavailable},
+		// Status code which is not retried
+		{"GET", 1, http.StatusNotFound, testRetryPolicy(), 1, http.StatusNotFound},
+		// Non-idempotent method
+		{"POST", 1, http.StatusServiceUnavailable, testRetryPolicy(), 1, http.StatusServiceUnavailable},
+		// Non-idempotent method opted in to retries
+		{"POST", 1, http.StatusServiceUnavailable, testRetryPolicy().ForEndpoint(2), 2, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		server, bodies := failingServer(tc.failures, tc.statusCode, nil)
+		client := NewDefaultClient(server.URL, false, server.Client(), WithRetryPolicy(tc.policy))
+
+		request, err := http.NewRequest(tc.method, server.URL, strings.NewReader("body"))
+		assert.NoError(t, err)
+
+		response, err := Do(client, request)
+		if assert.NoError(t, err) {
+			response.Body.Close()
+			assert.Equal(t, tc.status, response.StatusCode)
+		}
+		assert.Len(t, *bodies, tc.requests)
+		for _, body := range *bodies {
+			assert.Equal(t, "body", body)
+		}
+		server.Close()
+	}
+}
+
+func TestDoRetryUnrewindableBody(t *testing.T) {
+	server, bodies := failingServer(1, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+	client := NewDefaultClient(server.URL, false, server.Client(), WithRetryPolicy(testRetryPolicy()))
+
+	request, err := http.NewRequest("PUT", server.URL, ioutil.NopCloser(strings.NewReader("body")))
+	assert.NoError(t, err)
+
+	response, err := Do(client, request)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	}
+	assert.Len(t, *bodies, 1)
+}
+
+func TestDoRetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"1"}}
+
+	// Retry-After is honoured
+	server, bodies := failingServer(1, http.StatusTooManyRequests, header)
+	policy := testRetryPolicy()
+	client := NewDefaultClient(server.URL, false, server.Client(), WithRetryPolicy(policy))
+	request, err := http.NewRequest("GET", server.URL, nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	response, err := Do(client, request)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+	assert.True(t, time.Since(start) >= time.Second)
+	assert.Len(t, *bodies, 2)
+	server.Close()
+
+	// Retry-After exceeding the maximum backoff is not retried
+	server, bodies = failingServer(1, http.StatusTooManyRequests, header)
+	policy.MaxBackoff = 10 * time.Millisecond
+	client = NewDefaultClient(server.URL, false, server.Client(), WithRetryPolicy(policy))
+	request, err = http.NewRequest("GET", server.URL, nil)
+	assert.NoError(t, err)
+
+	response, err = Do(client, request)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	}
+	assert.Len(t, *bodies, 1)
+	server.Close()
+}
+
+func TestDoRetryCancelled(t *testing.T) {
+	server, bodies := failingServer(5, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+	policy := testRetryPolicy()
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+	client := NewDefaultClient(server.URL, false, server.Client(), WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	assert.NoError(t, err)
+
+	_, err = Do(client, request)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Len(t, *bodies, 1)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, time.Second, policy.backoff(5))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.backoff(1)
+		assert.True(t, delay >= 50*time.Millisecond && delay <= 150*time.Millisecond)
+	}
+}
@@ -0,0 +1,53 @@
+package restclient
+
+import (
+	"net/url"
+	"strings"
+)
+
+// EndpointURL returns the URL of the endpoint of a request annotation. The endpoint is
+// relative to the path of the base URL whether or not the path ends with a slash, so the
+// endpoint /photos of the base URL https://api.example.com/v1 or https://api.example.com/v1/
+// is https://api.example.com/v1/photos. The paths are joined as they are, a . or ..
+// segment substituted by a path parameter is sent rather than resolved, so it can not
+// send the request to another endpoint.
+func EndpointURL(baseURL, endpoint string) (string, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	if endpoint == "" {
+		return base.String(), nil
+	}
+	// A leading ./ keeps a colon in the first segment of the endpoint from being parsed
+	// as a scheme
+	ref, err := url.Parse("./" + strings.TrimPrefix(endpoint, "/"))
+	if err != nil {
+		return "", err
+	}
+	rawPath := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(ref.EscapedPath(), "./")
+	if base.Path, err = url.PathUnescape(rawPath); err != nil {
+		return "", err
+	}
+	base.RawPath = rawPath
+	base.RawQuery = ref.RawQuery
+	base.Fragment = ref.Fragment
+	base.RawFragment = ref.RawFragment
+	return base.String(), nil
+}
+
+// ResolveURL returns the URL of the value of a @URL parameter, which is resolved against
+// the base URL like a link. An absolute URL, such as a pagination link or a pre-signed
+// upload URL returned by the server, replaces the base URL and a URL starting with a
+// slash replaces the path of the base URL.
+func ResolveURL(baseURL, ref string) (string, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(u).String(), nil
+}
@@ -0,0 +1,62 @@
+package restclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointURL(t *testing.T) {
+	var testCases = []struct {
+		baseURL  string
+		endpoint string
+		expected string
+	}{
+		{"https://api.example.com", "/photos", "https://api.example.com/photos"},
+		{"https://api.example.com/", "/photos", "https://api.example.com/photos"},
+		{"https://api.example.com/v1", "/photos/1", "https://api.example.com/v1/photos/1"},
+		{"https://api.example.com/v1/", "/photos/1", "https://api.example.com/v1/photos/1"},
+		{"https://api.example.com/v1/", "photos", "https://api.example.com/v1/photos"},
+		{"https://api.example.com/v1", "/search?sort=date", "https://api.example.com/v1/search?sort=date"},
+		{"https://api.example.com/v1", "/files/a%2Fb", "https://api.example.com/v1/files/a%2Fb"},
+		{"https://api.example.com/v1", "/photos:search", "https://api.example.com/v1/photos:search"},
+		{"https://api.example.com/v1", "", "https://api.example.com/v1"},
+		{"", "/photos", "/photos"},
+		// Dot segments are not resolved
+		{"https://api.example.com/v1", "/photos/..", "https://api.example.com/v1/photos/.."},
+		{"https://api.example.com/v1", "/photos/.", "https://api.example.com/v1/photos/."},
+		{"https://api.example.com/v1/", "/photos/../admin", "https://api.example.com/v1/photos/../admin"},
+	}
+
+	for _, tc := range testCases {
+		u, err := EndpointURL(tc.baseURL, tc.endpoint)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, u, "%s %s", tc.baseURL, tc.endpoint)
+	}
+
+	_, err := EndpointURL("://api.example.com", "/photos")
+	assert.Error(t, err)
+}
+
+func TestResolveURL(t *testing.T) {
+	var testCases = []struct {
+		baseURL  string
+		ref      string
+		expected string
+	}{
+		{"https://api.example.com/v1/", "https://uploads.example.com/p/1?sig=abc", "https://uploads.example.com/p/1?sig=abc"},
+		{"https://api.example.com/v1/", "/v1/photos?page=2", "https://api.example.com/v1/photos?page=2"},
+		{"https://api.example.com/v1/", "photos?page=2", "https://api.example.com/v1/photos?page=2"},
+		{"https://api.example.com/v1", "photos?page=2", "https://api.example.com/photos?page=2"},
+		{"", "https://api.example.com/photos", "https://api.example.com/photos"},
+	}
+
+	for _, tc := range testCases {
+		u, err := ResolveURL(tc.baseURL, tc.ref)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, u, "%s %s", tc.baseURL, tc.ref)
+	}
+
+	_, err := ResolveURL("https://api.example.com", "http://[::1")
+	assert.Error(t, err)
+}